@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.twitch.tv/helix"
+	DefaultTimeout = 10 * time.Second
+)
+
+// Helix calls the Twitch API endpoints that github.com/nicklaw5/helix doesn't provide
+type Helix struct {
+	ClientID    string
+	AccessToken string
+	Username    string
+	BaseURL     string
+	Client      *http.Client
+
+	mu      sync.Mutex
+	userIDs map[string]string
+}
+
+func NewHelix(clientID string, accessToken string, username string) *Helix {
+	return &Helix{
+		ClientID:    clientID,
+		AccessToken: accessToken,
+		Username:    username,
+		BaseURL:     DefaultBaseURL,
+		Client:      &http.Client{Timeout: DefaultTimeout},
+		userIDs:     make(map[string]string),
+	}
+}
+
+// UserID returns the ID of the user with login, looking it up once
+func (h *Helix) UserID(login string) (string, error) {
+	login = strings.ToLower(strings.TrimPrefix(login, "@"))
+
+	h.mu.Lock()
+	id, ok := h.userIDs[login]
+	h.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var resp struct {
+		Data []struct {
+			ID    string `json:"id"`
+			Login string `json:"login"`
+		} `json:"data"`
+	}
+	err := h.do("GET", "/users", url.Values{"login": {login}}, nil, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Data) == 0 {
+		return "", fmt.Errorf("user %s not found", login)
+	}
+
+	h.mu.Lock()
+	h.userIDs[login] = resp.Data[0].ID
+	h.mu.Unlock()
+	return resp.Data[0].ID, nil
+}
+
+// SendWhisper whispers msg to the user with login
+func (h *Helix) SendWhisper(login string, msg string) error {
+	from, err := h.UserID(h.Username)
+	if err != nil {
+		return err
+	}
+
+	to, err := h.UserID(login)
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		Message string `json:"message"`
+	}{msg}
+	return h.do("POST", "/whispers", url.Values{"from_user_id": {from}, "to_user_id": {to}}, body, nil)
+}
+
+func (h *Helix) do(method string, path string, query url.Values, body interface{}, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s?%s", h.BaseURL, path, query.Encode()), r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Client-Id", h.ClientID)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", h.AccessToken))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Message)
+		}
+		return fmt.Errorf("%s %s: status code: %d", method, path, resp.StatusCode)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestHelix(t *testing.T, handler http.HandlerFunc) *Helix {
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+
+	h := NewHelix("clientID", "token", "me")
+	h.BaseURL = svr.URL
+	h.Client = svr.Client()
+	return h
+}
+
+func usersHandler(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != "/users" {
+		return false
+	}
+	login := r.URL.Query().Get("login")
+	if login == "nobody" {
+		fmt.Fprint(w, `{"data":[]}`)
+		return true
+	}
+	fmt.Fprintf(w, `{"data":[{"id":"id-%s","login":"%s"}]}`, login, login)
+	return true
+}
+
+func TestSendWhisper(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var gotQuery, gotMessage, gotAuth string
+		h := newTestHelix(t, func(w http.ResponseWriter, r *http.Request) {
+			if usersHandler(w, r) {
+				return
+			}
+			gotQuery = r.URL.RawQuery
+			gotAuth = r.Header.Get("Authorization")
+
+			var body struct {
+				Message string `json:"message"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			gotMessage = body.Message
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		err := h.SendWhisper("@Friend", "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "from_user_id=id-me&to_user_id=id-friend"; gotQuery != want {
+			t.Errorf("expected query %s, got %s", want, gotQuery)
+		}
+		if gotMessage != "hello" {
+			t.Errorf("expected message hello, got %s", gotMessage)
+		}
+		if gotAuth != "Bearer token" {
+			t.Errorf("expected authorization Bearer token, got %s", gotAuth)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		h := newTestHelix(t, func(w http.ResponseWriter, r *http.Request) {
+			usersHandler(w, r)
+		})
+
+		err := h.SendWhisper("nobody", "hello")
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		h := newTestHelix(t, func(w http.ResponseWriter, r *http.Request) {
+			if usersHandler(w, r) {
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"Unauthorized","status":401,"message":"missing scope"}`)
+		})
+
+		err := h.SendWhisper("friend", "hello")
+		if err == nil || err.Error() != "POST /whispers: missing scope" {
+			t.Errorf("expected missing scope error, got %v", err)
+		}
+	})
+}
+
+func TestModeration(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		query  string
+		body   string
+	}
+
+	tests := []struct {
+		Name   string
+		action func(*Helix) error
+		want   request
+	}{
+		{
+			"timeout",
+			func(h *Helix) error { return h.BanUser("channel", "foo", 10*time.Minute, "spam") },
+			request{"POST", "/moderation/bans", "broadcaster_id=id-channel&moderator_id=id-me", `{"data":{"user_id":"id-foo","duration":600,"reason":"spam"}}`},
+		},
+		{
+			"ban",
+			func(h *Helix) error { return h.BanUser("channel", "foo", 0, "") },
+			request{"POST", "/moderation/bans", "broadcaster_id=id-channel&moderator_id=id-me", `{"data":{"user_id":"id-foo"}}`},
+		},
+		{
+			"unban",
+			func(h *Helix) error { return h.UnbanUser("channel", "foo") },
+			request{"DELETE", "/moderation/bans", "broadcaster_id=id-channel&moderator_id=id-me&user_id=id-foo", ""},
+		},
+		{
+			"delete",
+			func(h *Helix) error { return h.DeleteMessage("channel", "abc") },
+			request{"DELETE", "/moderation/chat", "broadcaster_id=id-channel&message_id=abc&moderator_id=id-me", ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var got request
+			h := newTestHelix(t, func(w http.ResponseWriter, r *http.Request) {
+				if usersHandler(w, r) {
+					return
+				}
+				b, _ := io.ReadAll(r.Body)
+				got = request{r.Method, r.URL.Path, r.URL.RawQuery, string(b)}
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			err := test.action(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("expected request %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLookupUser(t *testing.T) {
+	created := time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	followed := time.Date(2020, 6, 7, 8, 9, 10, 0, time.UTC)
+
+	tests := []struct {
+		Name      string
+		follows   func(w http.ResponseWriter)
+		wantKnown bool
+		wantSince time.Time
+	}{
+		{
+			"following",
+			func(w http.ResponseWriter) {
+				fmt.Fprintf(w, `{"data":[{"followed_at":"%s"}]}`, followed.Format(time.RFC3339))
+			},
+			true,
+			followed,
+		},
+		{
+			"not following",
+			func(w http.ResponseWriter) { fmt.Fprint(w, `{"data":[]}`) },
+			true,
+			time.Time{},
+		},
+		{
+			"missing scope",
+			func(w http.ResponseWriter) { w.WriteHeader(http.StatusUnauthorized) },
+			false,
+			time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			h := newTestHelix(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/users" && r.URL.Query().Get("login") == "foo":
+					fmt.Fprintf(w, `{"data":[{"id":"id-foo","login":"foo","created_at":"%s"}]}`, created.Format(time.RFC3339))
+				case usersHandler(w, r):
+				case r.URL.Path == "/channels/followers":
+					if q := r.URL.Query(); q.Get("broadcaster_id") != "id-channel" || q.Get("user_id") != "id-foo" {
+						t.Errorf("unexpected follower query %s", r.URL.RawQuery)
+					}
+					test.follows(w)
+				}
+			})
+
+			info, err := h.LookupUser("channel", "@Foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !info.CreatedAt.Equal(created) {
+				t.Errorf("expected created at %s, got %s", created, info.CreatedAt)
+			}
+			if info.FollowKnown != test.wantKnown {
+				t.Errorf("expected follow known %t, got %t", test.wantKnown, info.FollowKnown)
+			}
+			if !info.FollowedAt.Equal(test.wantSince) {
+				t.Errorf("expected followed at %s, got %s", test.wantSince, info.FollowedAt)
+			}
+		})
+	}
+
+	t.Run("unknown user", func(t *testing.T) {
+		h := newTestHelix(t, func(w http.ResponseWriter, r *http.Request) {
+			usersHandler(w, r)
+		})
+
+		_, err := h.LookupUser("channel", "nobody")
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
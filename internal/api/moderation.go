@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/url"
+	"time"
+)
+
+// BanUser bans login from channel, or times them out when duration is positive
+func (h *Helix) BanUser(channel string, login string, duration time.Duration, reason string) error {
+	broadcaster, moderator, err := h.moderationIDs(channel)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserID(login)
+	if err != nil {
+		return err
+	}
+
+	type ban struct {
+		UserID   string `json:"user_id"`
+		Duration int    `json:"duration,omitempty"`
+		Reason   string `json:"reason,omitempty"`
+	}
+	body := struct {
+		Data ban `json:"data"`
+	}{ban{UserID: user, Duration: int(duration.Seconds()), Reason: reason}}
+
+	return h.do("POST", "/moderation/bans", url.Values{"broadcaster_id": {broadcaster}, "moderator_id": {moderator}}, body, nil)
+}
+
+// UnbanUser lifts a ban or timeout of login in channel
+func (h *Helix) UnbanUser(channel string, login string) error {
+	broadcaster, moderator, err := h.moderationIDs(channel)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserID(login)
+	if err != nil {
+		return err
+	}
+
+	return h.do("DELETE", "/moderation/bans", url.Values{"broadcaster_id": {broadcaster}, "moderator_id": {moderator}, "user_id": {user}}, nil, nil)
+}
+
+// DeleteMessage removes the message with messageID from channel
+func (h *Helix) DeleteMessage(channel string, messageID string) error {
+	broadcaster, moderator, err := h.moderationIDs(channel)
+	if err != nil {
+		return err
+	}
+
+	return h.do("DELETE", "/moderation/chat", url.Values{"broadcaster_id": {broadcaster}, "moderator_id": {moderator}, "message_id": {messageID}}, nil, nil)
+}
+
+func (h *Helix) moderationIDs(channel string) (string, string, error) {
+	broadcaster, err := h.UserID(channel)
+	if err != nil {
+		return "", "", err
+	}
+
+	moderator, err := h.UserID(h.Username)
+	if err != nil {
+		return "", "", err
+	}
+	return broadcaster, moderator, nil
+}
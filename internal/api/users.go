@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/atye/ttchat/internal/types"
+)
+
+// LookupUser returns when login's account was created and since when they follow channel.
+// Reading follows requires the moderator:read:followers scope, without it FollowKnown is false.
+func (h *Helix) LookupUser(channel string, login string) (types.UserInfo, error) {
+	login = strings.ToLower(strings.TrimPrefix(login, "@"))
+
+	var users struct {
+		Data []struct {
+			ID        string    `json:"id"`
+			Login     string    `json:"login"`
+			CreatedAt time.Time `json:"created_at"`
+		} `json:"data"`
+	}
+	err := h.do("GET", "/users", url.Values{"login": {login}}, nil, &users)
+	if err != nil {
+		return types.UserInfo{}, err
+	}
+	if len(users.Data) == 0 {
+		return types.UserInfo{}, fmt.Errorf("user %s not found", login)
+	}
+
+	user := users.Data[0]
+	h.mu.Lock()
+	h.userIDs[login] = user.ID
+	h.mu.Unlock()
+
+	info := types.UserInfo{Login: user.Login, CreatedAt: user.CreatedAt}
+
+	broadcaster, err := h.UserID(channel)
+	if err != nil {
+		return info, nil
+	}
+
+	var follows struct {
+		Data []struct {
+			FollowedAt time.Time `json:"followed_at"`
+		} `json:"data"`
+	}
+	err = h.do("GET", "/channels/followers", url.Values{"broadcaster_id": {broadcaster}, "user_id": {user.ID}}, nil, &follows)
+	if err != nil {
+		return info, nil
+	}
+
+	info.FollowKnown = true
+	if len(follows.Data) > 0 {
+		info.FollowedAt = follows.Data[0].FollowedAt
+	}
+	return info, nil
+}
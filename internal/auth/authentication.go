@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
@@ -30,8 +31,17 @@ var (
 	errFailedNonceValidation = errors.New("failed nonce validation")
 	errNoIdToken             = errors.New("id_token not found")
 	errNoAccessToken         = errors.New("access_token not found")
+
+	// ErrUnauthorized is returned when Twitch rejects an access token, such as when it expired or was revoked
+	ErrUnauthorized = errors.New("unauthorized")
 )
 
+// Validation is what Twitch knows about a valid access token
+type Validation struct {
+	Login  string   `json:"login"`
+	Scopes []string `json:"scopes"`
+}
+
 func GetAccessToken(conf *oauth2.Config, verifier TokenVerifyier, util Utils) (string, error) {
 	state, err := util.NewUUID()
 	if err != nil {
@@ -79,28 +89,33 @@ func GetAccessToken(conf *oauth2.Config, verifier TokenVerifyier, util Utils) (s
 	}
 }
 
-func ValidateAccessToken(accessToken string) error {
+func ValidateAccessToken(accessToken string) (Validation, error) {
 	r, err := http.NewRequest("GET", "https://id.twitch.tv/oauth2/validate", nil)
 	if err != nil {
-		return err
+		return Validation{}, err
 	}
 
 	r.Header.Set("Authorization", fmt.Sprintf("OAuth %s", accessToken))
 	resp, err := http.DefaultClient.Do(r)
 	if err != nil {
-		return err
+		return Validation{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("unauthorized")
+		return Validation{}, ErrUnauthorized
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("invaild access token: status code: %d", resp.StatusCode)
+		return Validation{}, fmt.Errorf("invaild access token: status code: %d", resp.StatusCode)
 	}
 
-	return nil
+	var v Validation
+	err = json.NewDecoder(resp.Body).Decode(&v)
+	if err != nil {
+		return Validation{}, fmt.Errorf("failed to decode validation: %v", err)
+	}
+	return v, nil
 }
 
 func buildUserLoginURL(conf *oauth2.Config, state string, nonce string) (string, error) {
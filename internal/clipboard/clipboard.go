@@ -0,0 +1,35 @@
+package clipboard
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Clipboard copies text to the system clipboard through the terminal with OSC 52,
+// which works over SSH in terminals that support it
+type Clipboard struct {
+	Tmux   bool // wrap the sequence for tmux to pass on to the terminal
+	Screen bool // wrap the sequence for GNU screen
+}
+
+// New returns a Clipboard wrapping sequences for tmux or screen when running inside them
+func New() *Clipboard {
+	return &Clipboard{
+		Tmux:   os.Getenv("TMUX") != "",
+		Screen: strings.HasPrefix(os.Getenv("TERM"), "screen"),
+	}
+}
+
+// Copy returns the escape sequence copying text for the caller to write to the terminal with its output
+func (c *Clipboard) Copy(text string) string {
+	seq := osc52.New(text)
+	switch {
+	case c.Tmux:
+		seq = seq.Tmux()
+	case c.Screen:
+		seq = seq.Screen()
+	}
+	return seq.String()
+}
@@ -0,0 +1,27 @@
+package clipboard
+
+import (
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	tests := []struct {
+		Name   string
+		tmux   bool
+		screen bool
+		want   string
+	}{
+		{"terminal", false, false, "\x1b]52;c;aGkgQHVzZXI=\x07"},
+		{"tmux", true, false, "\x1bPtmux;\x1b\x1b]52;c;aGkgQHVzZXI=\x07\x1b\\"},
+		{"screen", false, true, "\x1bP\x1b]52;c;aGkgQHVzZXI=\x07\x1b\\"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := &Clipboard{Tmux: test.tmux, Screen: test.screen}
+			if got := c.Copy("hi @user"); got != test.want {
+				t.Errorf("expected output %q, got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package duration
+
+import (
+	"strings"
+	"time"
+)
+
+// Format formats d without trailing zero units, like 10m instead of 10m0s
+func Format(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
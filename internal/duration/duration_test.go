@@ -0,0 +1,27 @@
+package duration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "30s"},
+		{10 * time.Minute, "10m"},
+		{90 * time.Second, "1m30s"},
+		{time.Hour, "1h"},
+		{24 * time.Hour, "24h"},
+		{time.Hour + 30*time.Minute, "1h30m"},
+		{time.Hour + 30*time.Second, "1h0m30s"},
+	}
+
+	for _, test := range tests {
+		if got := Format(test.d); got != test.want {
+			t.Errorf("expected %s, got %s", test.want, got)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package emote
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	DefaultCacheTTL = 24 * time.Hour
+)
+
+// Cache stores fetched emote sets on disk so they are only refetched after TTL
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	Now func() time.Time
+}
+
+type cacheEntry struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	Emotes    []Emote   `json:"emotes"`
+}
+
+func NewCache(dir string, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Cache{Dir: dir, TTL: ttl, Now: time.Now}
+}
+
+// Get returns the cached emotes for provider and key if they are younger than TTL,
+// otherwise it calls fetch and stores the result. A stale entry is returned if fetch fails.
+func (c *Cache) Get(provider string, key string, fetch func() ([]Emote, error)) ([]Emote, error) {
+	if c == nil {
+		return fetch()
+	}
+
+	path := c.path(provider, key)
+	entry, readErr := c.read(path)
+	if readErr == nil && c.Now().Sub(entry.FetchedAt) < c.TTL {
+		return entry.Emotes, nil
+	}
+
+	emotes, err := fetch()
+	if err != nil {
+		if readErr == nil {
+			return entry.Emotes, nil
+		}
+		return nil, err
+	}
+
+	// a failed write only costs a refetch next time
+	_ = c.write(path, cacheEntry{FetchedAt: c.Now(), Emotes: emotes})
+	return emotes, nil
+}
+
+func (c *Cache) path(provider string, key string) string {
+	return filepath.Join(c.Dir, provider, filepath.Base(key)+".json")
+}
+
+func (c *Cache) read(path string) (cacheEntry, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	var entry cacheEntry
+	err = json.Unmarshal(f, &entry)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	return entry, nil
+}
+
+func (c *Cache) write(path string, entry cacheEntry) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
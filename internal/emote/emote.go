@@ -0,0 +1,141 @@
+package emote
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Emote is a third-party emote as known by its provider
+type Emote struct {
+	ID       string `json:"id"`
+	Code     string `json:"code"`
+	Provider string `json:"provider"`
+}
+
+// Provider fetches emote sets from a third-party emote service
+type Provider interface {
+	Name() string
+	GlobalEmotes(ctx context.Context) ([]Emote, error)
+	ChannelEmotes(ctx context.Context, channelID string) ([]Emote, error)
+}
+
+const (
+	DefaultTimeout = 10 * time.Second
+)
+
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// Set is a concurrency safe collection of emotes keyed by code
+type Set struct {
+	mu     sync.RWMutex
+	emotes map[string]Emote
+}
+
+func NewSet() *Set {
+	return &Set{emotes: make(map[string]Emote)}
+}
+
+func (s *Set) Add(emotes ...Emote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range emotes {
+		if e.Code == "" {
+			continue
+		}
+		s.emotes[e.Code] = e
+	}
+}
+
+// Merge adds every emote in other to s
+func (s *Set) Merge(other *Set) {
+	other.mu.RLock()
+	emotes := make([]Emote, 0, len(other.emotes))
+	for _, e := range other.emotes {
+		emotes = append(emotes, e)
+	}
+	other.mu.RUnlock()
+	s.Add(emotes...)
+}
+
+func (s *Set) Lookup(code string) (Emote, bool) {
+	if s == nil {
+		return Emote{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.emotes[code]
+	return e, ok
+}
+
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.emotes)
+}
+
+// Complete returns the sorted codes that start with prefix, ignoring case
+func (s *Set) Complete(prefix string) []string {
+	if s == nil || prefix == "" {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lower := strings.ToLower(prefix)
+	var codes []string
+	for code := range s.emotes {
+		if strings.HasPrefix(strings.ToLower(code), lower) {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Loader fetches emotes from providers through a cache
+type Loader struct {
+	Providers []Provider
+	Cache     *Cache
+	Log       *log.Logger
+}
+
+// LoadGlobal adds every provider's global emotes to set
+func (l Loader) LoadGlobal(ctx context.Context, set *Set) {
+	for _, p := range l.Providers {
+		p := p
+		emotes, err := l.Cache.Get(p.Name(), "global", func() ([]Emote, error) {
+			return p.GlobalEmotes(ctx)
+		})
+		if err != nil {
+			l.Log.Printf("emote: loading %s global emotes: %v\n", p.Name(), err)
+			continue
+		}
+		set.Add(emotes...)
+	}
+}
+
+// LoadChannel adds every provider's emotes for channelID to set
+func (l Loader) LoadChannel(ctx context.Context, channelID string, set *Set) {
+	if channelID == "" {
+		return
+	}
+	for _, p := range l.Providers {
+		p := p
+		emotes, err := l.Cache.Get(p.Name(), channelID, func() ([]Emote, error) {
+			return p.ChannelEmotes(ctx, channelID)
+		})
+		if err != nil {
+			l.Log.Printf("emote: loading %s emotes for %s: %v\n", p.Name(), channelID, err)
+			continue
+		}
+		set.Add(emotes...)
+	}
+}
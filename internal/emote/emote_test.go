@@ -0,0 +1,234 @@
+package emote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, routes map[string]string) (*httptest.Server, *int) {
+	hits := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(svr.Close)
+	return svr, &hits
+}
+
+func codes(emotes []Emote) []string {
+	var c []string
+	for _, e := range emotes {
+		c = append(c, e.Code)
+	}
+	sort.Strings(c)
+	return c
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		Name        string
+		provider    string
+		routes      map[string]string
+		wantGlobal  []string
+		wantChannel []string
+	}{
+		{
+			"bttv",
+			BTTV,
+			map[string]string{
+				"/cached/emotes/global":    `[{"id":"1","code":"catJAM"}]`,
+				"/cached/users/twitch/123": `{"channelEmotes":[{"id":"2","code":"monkaGIGA"}],"sharedEmotes":[{"id":"3","code":"pepeD"}]}`,
+			},
+			[]string{"catJAM"},
+			[]string{"monkaGIGA", "pepeD"},
+		},
+		{
+			"ffz",
+			FFZ,
+			map[string]string{
+				"/set/global":  `{"default_sets":[3],"sets":{"3":{"emoticons":[{"id":1,"name":"LilZ"}]},"4":{"emoticons":[{"id":2,"name":"hidden"}]}}}`,
+				"/room/id/123": `{"room":{"set":7},"sets":{"7":{"emoticons":[{"id":3,"name":"OMEGALUL"}]}}}`,
+			},
+			[]string{"LilZ"},
+			[]string{"OMEGALUL"},
+		},
+		{
+			"7tv",
+			SevenTV,
+			map[string]string{
+				"/emote-sets/global":  `{"emotes":[{"id":"a","name":"EZ"}]}`,
+				"/users/twitch/123":   `{"emote_set":{"emotes":[{"id":"b","name":"Clap"}]}}`,
+				"/users/twitch/other": `{}`,
+			},
+			[]string{"EZ"},
+			[]string{"Clap"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			svr, _ := newTestServer(t, test.routes)
+
+			p, err := NewProvider(test.provider, svr.URL, svr.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			global, err := p.GlobalEmotes(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := codes(global); !reflect.DeepEqual(got, test.wantGlobal) {
+				t.Errorf("expected global emotes %v, got %v", test.wantGlobal, got)
+			}
+
+			channel, err := p.ChannelEmotes(context.Background(), "123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := codes(channel); !reflect.DeepEqual(got, test.wantChannel) {
+				t.Errorf("expected channel emotes %v, got %v", test.wantChannel, got)
+			}
+
+			for _, e := range channel {
+				if e.Provider != test.provider {
+					t.Errorf("expected provider %s, got %s", test.provider, e.Provider)
+				}
+			}
+		})
+	}
+
+	t.Run("channel not found", func(t *testing.T) {
+		svr, _ := newTestServer(t, nil)
+		for _, name := range []string{BTTV, FFZ, SevenTV} {
+			p, err := NewProvider(name, svr.URL, svr.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			emotes, err := p.ChannelEmotes(context.Background(), "123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(emotes) != 0 {
+				t.Errorf("expected no %s emotes, got %v", name, emotes)
+			}
+		}
+	})
+
+	t.Run("global not found", func(t *testing.T) {
+		svr, _ := newTestServer(t, nil)
+		for _, name := range []string{BTTV, FFZ, SevenTV} {
+			p, err := NewProvider(name, svr.URL, svr.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.GlobalEmotes(context.Background()); err == nil {
+				t.Errorf("expected an error for the global emotes of %s", name)
+			}
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := NewProvider("foo", "", nil)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(t.TempDir(), time.Hour)
+	c.Now = func() time.Time { return now }
+
+	fetches := 0
+	fetchErr := error(nil)
+	fetch := func() ([]Emote, error) {
+		fetches++
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return []Emote{{ID: "1", Code: "catJAM", Provider: BTTV}}, nil
+	}
+
+	get := func() []Emote {
+		t.Helper()
+		emotes, err := c.Get(BTTV, "global", fetch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return emotes
+	}
+
+	get()
+	get()
+	if fetches != 1 {
+		t.Errorf("expected 1 fetch before ttl, got %d", fetches)
+	}
+
+	now = now.Add(2 * time.Hour)
+	get()
+	if fetches != 2 {
+		t.Errorf("expected 2 fetches after ttl, got %d", fetches)
+	}
+
+	now = now.Add(2 * time.Hour)
+	fetchErr = fmt.Errorf("offline")
+	if got := codes(get()); !reflect.DeepEqual(got, []string{"catJAM"}) {
+		t.Errorf("expected stale emotes when fetch fails, got %v", got)
+	}
+
+	_, err := c.Get(BTTV, "456", fetch)
+	if err == nil {
+		t.Error("expected error without a cached entry")
+	}
+}
+
+func TestLoader(t *testing.T) {
+	svr, hits := newTestServer(t, map[string]string{
+		"/cached/emotes/global":    `[{"id":"1","code":"catJAM"}]`,
+		"/cached/users/twitch/123": `{"channelEmotes":[{"id":"2","code":"catKISS"}]}`,
+	})
+
+	p, err := NewProvider(BTTV, svr.URL, svr.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := Loader{
+		Providers: []Provider{p},
+		Cache:     NewCache(t.TempDir(), time.Hour),
+		Log:       log.New(io.Discard, "", 0),
+	}
+
+	for i := 0; i < 2; i++ {
+		set := NewSet()
+		l.LoadGlobal(context.Background(), set)
+		l.LoadChannel(context.Background(), "123", set)
+
+		if got := set.Complete("cat"); !reflect.DeepEqual(got, []string{"catJAM", "catKISS"}) {
+			t.Errorf("expected completions %v, got %v", []string{"catJAM", "catKISS"}, got)
+		}
+		if _, ok := set.Lookup("catJAM"); !ok {
+			t.Error("expected catJAM in set")
+		}
+	}
+
+	if *hits != 2 {
+		t.Errorf("expected 2 requests with a warm cache, got %d", *hits)
+	}
+}
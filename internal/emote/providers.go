@@ -0,0 +1,233 @@
+package emote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	BTTV    = "bttv"
+	FFZ     = "ffz"
+	SevenTV = "7tv"
+
+	DefaultBTTVURL    = "https://api.betterttv.net/3"
+	DefaultFFZURL     = "https://api.frankerfacez.com/v1"
+	DefaultSevenTVURL = "https://7tv.io/v3"
+)
+
+var (
+	errNotFound = errors.New("not found")
+)
+
+// NewProvider returns the provider registered under name, talking to baseURL.
+// An empty baseURL uses the provider's public API.
+func NewProvider(name string, baseURL string, client *http.Client) (Provider, error) {
+	if client == nil {
+		client = defaultClient
+	}
+
+	switch strings.ToLower(name) {
+	case BTTV:
+		if baseURL == "" {
+			baseURL = DefaultBTTVURL
+		}
+		return BetterTTV{BaseURL: baseURL, Client: client}, nil
+	case FFZ:
+		if baseURL == "" {
+			baseURL = DefaultFFZURL
+		}
+		return FrankerFaceZ{BaseURL: baseURL, Client: client}, nil
+	case SevenTV:
+		if baseURL == "" {
+			baseURL = DefaultSevenTVURL
+		}
+		return SevenTVProvider{BaseURL: baseURL, Client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown emote provider %q", name)
+}
+
+type BetterTTV struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+var _ Provider = BetterTTV{}
+
+type bttvEmote struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+}
+
+func (p BetterTTV) Name() string {
+	return BTTV
+}
+
+func (p BetterTTV) GlobalEmotes(ctx context.Context) ([]Emote, error) {
+	var resp []bttvEmote
+	err := getJSON(ctx, p.Client, fmt.Sprintf("%s/cached/emotes/global", p.BaseURL), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return p.convert(resp), nil
+}
+
+func (p BetterTTV) ChannelEmotes(ctx context.Context, channelID string) ([]Emote, error) {
+	var resp struct {
+		ChannelEmotes []bttvEmote `json:"channelEmotes"`
+		SharedEmotes  []bttvEmote `json:"sharedEmotes"`
+	}
+	err := getChannelJSON(ctx, p.Client, fmt.Sprintf("%s/cached/users/twitch/%s", p.BaseURL, channelID), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return p.convert(append(resp.ChannelEmotes, resp.SharedEmotes...)), nil
+}
+
+func (p BetterTTV) convert(in []bttvEmote) []Emote {
+	emotes := make([]Emote, 0, len(in))
+	for _, e := range in {
+		emotes = append(emotes, Emote{ID: e.ID, Code: e.Code, Provider: BTTV})
+	}
+	return emotes
+}
+
+type FrankerFaceZ struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+var _ Provider = FrankerFaceZ{}
+
+type ffzSet struct {
+	Emoticons []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"emoticons"`
+}
+
+func (p FrankerFaceZ) Name() string {
+	return FFZ
+}
+
+func (p FrankerFaceZ) GlobalEmotes(ctx context.Context) ([]Emote, error) {
+	var resp struct {
+		DefaultSets []int             `json:"default_sets"`
+		Sets        map[string]ffzSet `json:"sets"`
+	}
+	err := getJSON(ctx, p.Client, fmt.Sprintf("%s/set/global", p.BaseURL), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var emotes []Emote
+	for _, id := range resp.DefaultSets {
+		emotes = append(emotes, p.convert(resp.Sets[strconv.Itoa(id)])...)
+	}
+	return emotes, nil
+}
+
+func (p FrankerFaceZ) ChannelEmotes(ctx context.Context, channelID string) ([]Emote, error) {
+	var resp struct {
+		Room struct {
+			Set int `json:"set"`
+		} `json:"room"`
+		Sets map[string]ffzSet `json:"sets"`
+	}
+	err := getChannelJSON(ctx, p.Client, fmt.Sprintf("%s/room/id/%s", p.BaseURL, channelID), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return p.convert(resp.Sets[strconv.Itoa(resp.Room.Set)]), nil
+}
+
+func (p FrankerFaceZ) convert(set ffzSet) []Emote {
+	emotes := make([]Emote, 0, len(set.Emoticons))
+	for _, e := range set.Emoticons {
+		emotes = append(emotes, Emote{ID: strconv.Itoa(e.ID), Code: e.Name, Provider: FFZ})
+	}
+	return emotes
+}
+
+type SevenTVProvider struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+var _ Provider = SevenTVProvider{}
+
+type sevenTVSet struct {
+	Emotes []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"emotes"`
+}
+
+func (p SevenTVProvider) Name() string {
+	return SevenTV
+}
+
+func (p SevenTVProvider) GlobalEmotes(ctx context.Context) ([]Emote, error) {
+	var resp sevenTVSet
+	err := getJSON(ctx, p.Client, fmt.Sprintf("%s/emote-sets/global", p.BaseURL), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return p.convert(resp), nil
+}
+
+func (p SevenTVProvider) ChannelEmotes(ctx context.Context, channelID string) ([]Emote, error) {
+	var resp struct {
+		EmoteSet sevenTVSet `json:"emote_set"`
+	}
+	err := getChannelJSON(ctx, p.Client, fmt.Sprintf("%s/users/twitch/%s", p.BaseURL, channelID), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return p.convert(resp.EmoteSet), nil
+}
+
+func (p SevenTVProvider) convert(set sevenTVSet) []Emote {
+	emotes := make([]Emote, 0, len(set.Emotes))
+	for _, e := range set.Emotes {
+		emotes = append(emotes, Emote{ID: e.ID, Code: e.Name, Provider: SevenTV})
+	}
+	return emotes
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	r, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("GET %s: %w", url, errNotFound)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status code: %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getChannelJSON is getJSON for the emotes of a channel, leaving v empty for channels
+// without any emotes on a provider, which are reported as not found
+func getChannelJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	err := getJSON(ctx, client, url, v)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	return err
+}
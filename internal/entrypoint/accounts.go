@@ -0,0 +1,265 @@
+package entrypoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/atye/ttchat/internal/api"
+	"github.com/atye/ttchat/internal/auth"
+	"github.com/atye/ttchat/internal/auth/openid"
+	"github.com/atye/ttchat/internal/token"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/nicklaw5/helix"
+)
+
+// Account is a Twitch user ttchat logs in as. The channels of an account are chatted in as that user.
+type Account struct {
+	ClientID     string   `yaml:"clientID"`
+	Username     string   `yaml:"username"`
+	RedirectPort string   `yaml:"redirectPort"`
+	Channels     []string `yaml:"channels"`
+}
+
+// session is a logged in account
+type session struct {
+	name        string
+	account     Account
+	accessToken string
+	displayName string
+	helix       *helix.Client
+	api         *api.Helix
+	whispers    bool // the token has the whisper scopes
+}
+
+const (
+	// DefaultAccount is the name of the account set by the top level clientID and username
+	DefaultAccount = "default"
+)
+
+var (
+	errTokenMismatch = errors.New("token doesn't match the account")
+)
+
+// setAccounts adds the top level account to the configured accounts and fills in what accounts leave out
+func setAccounts(conf *Config) []problem {
+	if conf.Accounts == nil {
+		conf.Accounts = make(map[string]Account)
+	}
+
+	var problems []problem
+	if conf.Username != "" {
+		if _, ok := conf.Accounts[DefaultAccount]; ok {
+			problems = append(problems, problem{path: []string{"accounts", DefaultAccount}, msg: fmt.Sprintf("account %q is set by the top level username, rename the account", DefaultAccount)})
+		}
+		conf.Accounts[DefaultAccount] = Account{ClientID: conf.ClientID, Username: conf.Username, RedirectPort: conf.RedirectPort}
+	}
+	if len(conf.Accounts) == 0 {
+		if conf.ClientID == "" {
+			problems = append(problems, problem{path: []string{"clientID"}, msg: "no clientID provided"})
+		}
+		return append(problems, problem{path: []string{"username"}, msg: "no username provided"})
+	}
+
+	for _, name := range accountNames(*conf) {
+		a := conf.Accounts[name]
+		if a.ClientID == "" {
+			a.ClientID = conf.ClientID
+		}
+		if a.ClientID == "" {
+			if name == DefaultAccount {
+				problems = append(problems, problem{path: []string{"clientID"}, msg: "no clientID provided"})
+			} else {
+				problems = append(problems, problem{path: []string{"accounts", name}, msg: fmt.Sprintf("no clientID provided for account %q", name)})
+			}
+		}
+		if a.Username == "" {
+			problems = append(problems, problem{path: []string{"accounts", name}, msg: fmt.Sprintf("no username provided for account %q", name)})
+		}
+		if a.RedirectPort == "" {
+			a.RedirectPort = conf.RedirectPort
+		}
+		conf.Accounts[name] = a
+	}
+	return problems
+}
+
+// pickAccount returns the account named by the --account flag, or the only or default account
+func pickAccount(conf Config, name string) (string, error) {
+	if name != "" {
+		if _, ok := conf.Accounts[name]; !ok {
+			return "", fmt.Errorf("unknown account %q, expected one of %s", name, strings.Join(accountNames(conf), ", "))
+		}
+		return name, nil
+	}
+	if _, ok := conf.Accounts[DefaultAccount]; ok {
+		return DefaultAccount, nil
+	}
+	if len(conf.Accounts) == 1 {
+		return accountNames(conf)[0], nil
+	}
+	return "", fmt.Errorf("%d accounts configured, choose one with --account: %s", len(conf.Accounts), strings.Join(accountNames(conf), ", "))
+}
+
+func accountNames(conf Config) []string {
+	var names []string
+	for name := range conf.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// channelAccounts returns the account each channel is chatted in as, main for channels no account lists
+func channelAccounts(conf Config, channels []string, main string) (map[string]string, error) {
+	assigned := make(map[string]string)
+	for _, name := range accountNames(conf) {
+		for _, c := range conf.Accounts[name].Channels {
+			c = strings.ToLower(strings.TrimPrefix(c, "#"))
+			if other, ok := assigned[c]; ok {
+				return nil, fmt.Errorf("channel %q is listed by accounts %q and %q", c, other, name)
+			}
+			assigned[c] = name
+		}
+	}
+
+	accounts := make(map[string]string)
+	for _, c := range channels {
+		accounts[c] = main
+		if name, ok := assigned[strings.ToLower(c)]; ok {
+			accounts[c] = name
+		}
+	}
+	return accounts, nil
+}
+
+// login gets an access token for the account, reusing its stored token while Twitch accepts it for the
+// account's user and scopes, and looks up the account's display name. With whispers, a new login also
+// asks for the whisper scopes, but tokens without them are still used, with whispers off.
+func login(logger *log.Logger, conf Config, name string, accessToken string, store *token.Store, whispers bool) (*session, error) {
+	account := conf.Accounts[name]
+	required := scopes(conf, false)
+
+	var v auth.Validation
+	if accessToken != "" {
+		var err error
+		v, err = auth.ValidateAccessToken(accessToken)
+		if err == nil {
+			err = checkToken(v, account, required)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("--token: %w", err)
+		}
+	}
+
+	if accessToken == "" {
+		stored, err := store.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		if stored != "" {
+			v, err = auth.ValidateAccessToken(stored)
+			if err == nil {
+				err = checkToken(v, account, required)
+			}
+			switch {
+			case err == nil:
+				accessToken = stored
+			case errors.Is(err, auth.ErrUnauthorized) || errors.Is(err, errTokenMismatch):
+				logger.Printf("stored token of account %s: %v\n", name, err)
+				err = store.Delete(name)
+				if err != nil {
+					logger.Printf("deleting token of account %s: %v\n", name, err)
+				}
+			default:
+				logger.Printf("validating token of account %s: %v\n", name, err)
+			}
+		}
+	}
+
+	if accessToken == "" {
+		provider, err := oidc.NewProvider(context.Background(), "https://id.twitch.tv/oauth2")
+		if err != nil {
+			return nil, err
+		}
+		oidcVerifier := openid.CoreOSVerifier{Verifier: provider.Verifier(&oidc.Config{ClientID: account.ClientID})}
+
+		accessToken, err = getAccessToken(logger, account, scopes(conf, whispers), oidcVerifier)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err = auth.ValidateAccessToken(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		err = checkToken(v, account, required)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", name, err)
+		}
+
+		err = store.Save(name, accessToken)
+		if err != nil {
+			logger.Printf("saving token of account %s: %v\n", name, err)
+		}
+	}
+
+	if missing := missingScopes(v, whisperScopes); whispers && len(missing) > 0 {
+		logger.Printf("whispers are off, the token of account %s is missing scopes %s\n", name, strings.Join(missing, ", "))
+		whispers = false
+	}
+
+	tc, err := helix.NewClient(&helix.Options{
+		ClientID:        account.ClientID,
+		UserAccessToken: accessToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	displayName, err := getUserDisplayName(account.Username, tc)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", name, err)
+	}
+
+	return &session{
+		name:        name,
+		account:     account,
+		accessToken: accessToken,
+		displayName: displayName,
+		helix:       tc,
+		api:         api.NewHelix(account.ClientID, accessToken, account.Username),
+		whispers:    whispers,
+	}, nil
+}
+
+// checkToken returns an error when the token was given to another user than the account's
+// or lacks scopes ttchat needs, such as after moderation was turned on
+func checkToken(v auth.Validation, account Account, scopes []string) error {
+	if !strings.EqualFold(v.Login, account.Username) {
+		return fmt.Errorf("%w: logged in as %q, not %q", errTokenMismatch, v.Login, account.Username)
+	}
+	if missing := missingScopes(v, scopes); len(missing) > 0 {
+		return fmt.Errorf("%w: missing scopes %s", errTokenMismatch, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingScopes returns the scopes the token doesn't have
+func missingScopes(v auth.Validation, scopes []string) []string {
+	have := make(map[string]bool)
+	for _, s := range v.Scopes {
+		have[s] = true
+	}
+	var missing []string
+	for _, s := range scopes {
+		// openid is only used to log in and isn't a scope of the access token
+		if s != "openid" && !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
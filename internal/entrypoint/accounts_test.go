@@ -0,0 +1,196 @@
+package entrypoint
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/atye/ttchat/internal/auth"
+)
+
+func TestAccounts(t *testing.T) {
+	tests := []struct {
+		Name     string
+		conf     Config
+		flag     string
+		channels []string
+		main     string
+		accounts map[string]string
+		err      bool
+	}{
+		{
+			Name:     "top level account",
+			conf:     Config{ClientID: "id", Username: "me"},
+			channels: []string{"chess"},
+			main:     DefaultAccount,
+			accounts: map[string]string{"chess": DefaultAccount},
+		},
+		{
+			Name: "channels of an account",
+			conf: Config{ClientID: "id", Username: "me", Accounts: map[string]Account{
+				"bot": {Username: "mybot", Channels: []string{"#Chess"}},
+			}},
+			channels: []string{"chess", "other"},
+			main:     DefaultAccount,
+			accounts: map[string]string{"chess": "bot", "other": DefaultAccount},
+		},
+		{
+			Name: "account flag",
+			conf: Config{ClientID: "id", Username: "me", Accounts: map[string]Account{
+				"bot": {Username: "mybot"},
+			}},
+			flag:     "bot",
+			channels: []string{"chess"},
+			main:     "bot",
+			accounts: map[string]string{"chess": "bot"},
+		},
+		{
+			Name: "only account",
+			conf: Config{ClientID: "id", Accounts: map[string]Account{
+				"bot": {Username: "mybot"},
+			}},
+			channels: []string{"chess"},
+			main:     "bot",
+			accounts: map[string]string{"chess": "bot"},
+		},
+		{
+			Name: "several accounts without a flag",
+			conf: Config{ClientID: "id", Accounts: map[string]Account{
+				"main": {Username: "me"},
+				"bot":  {Username: "mybot"},
+			}},
+			err: true,
+		},
+		{
+			Name: "unknown account flag",
+			conf: Config{ClientID: "id", Username: "me"},
+			flag: "bot",
+			err:  true,
+		},
+		{
+			Name: "channel listed twice",
+			conf: Config{ClientID: "id", Username: "me", Accounts: map[string]Account{
+				"bot":   {Username: "mybot", Channels: []string{"chess"}},
+				"other": {Username: "other", Channels: []string{"chess"}},
+			}},
+			channels: []string{"chess"},
+			err:      true,
+		},
+		{
+			Name: "no username",
+			conf: Config{ClientID: "id"},
+			err:  true,
+		},
+		{
+			Name: "no clientID",
+			conf: Config{Accounts: map[string]Account{"bot": {Username: "mybot"}}},
+			err:  true,
+		},
+		{
+			Name: "default account taken",
+			conf: Config{ClientID: "id", Username: "me", Accounts: map[string]Account{
+				DefaultAccount: {Username: "mybot"},
+			}},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			conf := test.conf
+			conf.RedirectPort = DefaultRedirectPort
+
+			main, accounts, err := func() (string, map[string]string, error) {
+				if problems := setAccounts(&conf); len(problems) > 0 {
+					return "", nil, fmt.Errorf(problems[0].msg)
+				}
+				main, err := pickAccount(conf, test.flag)
+				if err != nil {
+					return "", nil, err
+				}
+				accounts, err := channelAccounts(conf, test.channels, main)
+				return main, accounts, err
+			}()
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if main != test.main {
+				t.Errorf("expected main account %q, got %q", test.main, main)
+			}
+			if !reflect.DeepEqual(accounts, test.accounts) {
+				t.Errorf("expected accounts %v, got %v", test.accounts, accounts)
+			}
+			for name, a := range conf.Accounts {
+				if a.ClientID != "id" || a.RedirectPort != DefaultRedirectPort {
+					t.Errorf("expected account %s to inherit clientID and redirectPort, got %+v", name, a)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	account := Account{Username: "Me"}
+	tests := []struct {
+		Name   string
+		v      auth.Validation
+		scopes []string
+		err    bool
+	}{
+		{"matching", auth.Validation{Login: "me", Scopes: []string{"chat:edit", "chat:read"}}, []string{"openid", "chat:read", "chat:edit"}, false},
+		{"extra scopes", auth.Validation{Login: "me", Scopes: []string{"chat:edit", "chat:read", "whispers:read"}}, []string{"chat:read"}, false},
+		{"missing scope", auth.Validation{Login: "me", Scopes: []string{"chat:read", "chat:edit"}}, []string{"chat:read", "chat:edit", "whispers:read"}, true},
+		{"another user", auth.Validation{Login: "someone", Scopes: []string{"chat:read"}}, []string{"chat:read"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := checkToken(test.v, account, test.scopes)
+			if test.err != (err != nil) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			if err != nil && !errors.Is(err, errTokenMismatch) {
+				t.Errorf("expected a mismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestScopes(t *testing.T) {
+	tests := []struct {
+		Name     string
+		conf     Config
+		whispers bool
+		want     []string
+	}{
+		{"chat", Config{}, false, []string{"openid", "chat:read", "chat:edit"}},
+		{"whispers", Config{}, true, []string{"openid", "chat:read", "chat:edit", "whispers:read", "user:manage:whispers"}},
+		{"moderation", Config{Moderation: ModConfig{Enabled: true}}, false, []string{"openid", "chat:read", "chat:edit", "moderator:manage:banned_users", "moderator:manage:chat_messages", "moderator:read:followers"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := scopes(test.conf, test.whispers); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected scopes %v, got %v", test.want, got)
+			}
+		})
+	}
+
+	t.Run("tokens without whisper scopes are kept", func(t *testing.T) {
+		v := auth.Validation{Login: "me", Scopes: []string{"chat:read", "chat:edit"}}
+		if err := checkToken(v, Account{Username: "me"}, scopes(Config{}, false)); err != nil {
+			t.Errorf("expected the token to be kept, got %v", err)
+		}
+		if want := whisperScopes; !reflect.DeepEqual(missingScopes(v, whisperScopes), want) {
+			t.Errorf("expected missing whisper scopes %v, got %v", want, missingScopes(v, whisperScopes))
+		}
+	})
+}
@@ -0,0 +1,583 @@
+package entrypoint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/atye/ttchat/internal/emote"
+	"github.com/atye/ttchat/internal/filter"
+	"github.com/atye/ttchat/internal/irc"
+	"github.com/atye/ttchat/internal/notify"
+	"github.com/atye/ttchat/internal/terminal"
+	"github.com/atye/ttchat/internal/theme"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix = "TTCHAT_"
+	maxPanes  = 4
+)
+
+var (
+	yamlLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+// problem is something wrong with the configuration and the yaml path of the value at fault
+type problem struct {
+	path []string
+	line int // set instead of path by the yaml decoder
+	msg  string
+}
+
+// configError lists every problem of a configuration file
+type configError struct {
+	file     string
+	root     *yaml.Node
+	env      map[string]string // environment variables by the path they override
+	problems []problem
+}
+
+// Error lists the problems in the order of their lines, followed by those without one
+func (e *configError) Error() string {
+	type entry struct {
+		line int
+		text string
+	}
+	var entries []entry
+	for _, p := range e.problems {
+		if name, ok := e.env[strings.Join(p.path, ".")]; ok {
+			entries = append(entries, entry{text: fmt.Sprintf("%s: %s", name, p.msg)})
+			continue
+		}
+		line := p.line
+		if line == 0 {
+			line = lineOf(e.root, p.path)
+		}
+		if line == 0 {
+			entries = append(entries, entry{text: fmt.Sprintf("%s: %s", e.file, p.msg)})
+			continue
+		}
+		entries = append(entries, entry{line: line, text: fmt.Sprintf("%s:%d: %s", e.file, line, p.msg)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].line != 0 && (entries[j].line == 0 || entries[i].line < entries[j].line)
+	})
+
+	lines := make([]string, len(entries))
+	for i, en := range entries {
+		lines[i] = en.text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// configPaths returns where the configuration file is looked for, in order: the --config flag,
+// $TTCHAT_CONFIG, $XDG_CONFIG_HOME/ttchat/config.yaml and $HOME/.ttchat/config.yaml
+func configPaths(hd string, flag string) []string {
+	if flag != "" {
+		return []string{flag}
+	}
+	if p := os.Getenv(envPrefix + "CONFIG"); p != "" {
+		return []string{p}
+	}
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = filepath.Join(hd, ".config")
+	}
+	return []string{filepath.Join(xdg, "ttchat", "config.yaml"), filepath.Join(hd, ".ttchat", "config.yaml")}
+}
+
+// findConfig returns the first configuration file that exists
+func findConfig(hd string, flag string) (string, error) {
+	paths := configPaths(hd, flag)
+	for _, p := range paths {
+		_, err := os.Stat(p)
+		if err == nil {
+			return p, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return paths[0], fmt.Errorf("no configuration file at %s, run ttchat config init to write one: %w", strings.Join(paths, " or "), os.ErrNotExist)
+}
+
+// dirs are where ttchat keeps what it writes besides the configuration
+type dirs struct {
+	data  string // the ignore list
+	state string // access tokens and sent messages
+	cache string // emote sets
+}
+
+// dataDirs returns $XDG_DATA_HOME/ttchat, $XDG_STATE_HOME/ttchat and $XDG_CACHE_HOME/ttchat, or their
+// defaults in $HOME. A $HOME/.ttchat directory, from earlier versions or holding the configuration, keeps everything.
+func dataDirs(hd string) dirs {
+	legacy := filepath.Join(hd, ".ttchat")
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return dirs{data: legacy, state: legacy, cache: filepath.Join(legacy, "cache")}
+	}
+	return dirs{
+		data:  xdgDir("XDG_DATA_HOME", filepath.Join(hd, ".local", "share")),
+		state: xdgDir("XDG_STATE_HOME", filepath.Join(hd, ".local", "state")),
+		cache: xdgDir("XDG_CACHE_HOME", filepath.Join(hd, ".cache")),
+	}
+}
+
+// xdgDir returns the ttchat directory of the base directory named by env, or of def when it isn't set.
+// Relative base directories are ignored as the XDG specification asks.
+func xdgDir(env string, def string) string {
+	base := os.Getenv(env)
+	if !filepath.IsAbs(base) {
+		base = def
+	}
+	return filepath.Join(base, "ttchat")
+}
+
+// getConfig reads the configuration file at path, overridden by TTCHAT_* environment variables,
+// and reports all of its problems. Anonymous users need no account and no file.
+func getConfig(path string, anonymous bool) (Config, error) {
+	f, err := os.ReadFile(path)
+	if anonymous && errors.Is(err, os.ErrNotExist) {
+		f, err = nil, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	var root yaml.Node
+	err = yaml.Unmarshal(f, &root)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var conf Config
+	var problems []problem
+	dec := yaml.NewDecoder(bytes.NewReader(f))
+	dec.KnownFields(true)
+	err = dec.Decode(&conf)
+	var typeErr *yaml.TypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+	case errors.As(err, &typeErr):
+		for _, e := range typeErr.Errors {
+			p := problem{msg: e}
+			if m := yamlLine.FindStringSubmatch(e); m != nil {
+				p.line, _ = strconv.Atoi(m[1])
+				p.msg = m[2]
+			}
+			problems = append(problems, p)
+		}
+	default:
+		return Config{}, fmt.Errorf("%s: %v", path, err)
+	}
+
+	env, envProblems := applyEnv(&conf, os.Environ())
+	problems = append(problems, envProblems...)
+
+	if conf.Theme.File != "" && !filepath.IsAbs(conf.Theme.File) {
+		conf.Theme.File = filepath.Join(filepath.Dir(path), conf.Theme.File)
+	}
+	if conf.RedirectPort == "" {
+		conf.RedirectPort = DefaultRedirectPort
+	}
+	if conf.Names.Contrast == 0 {
+		conf.Names.Contrast = DefaultNameContrast
+	}
+	if len(conf.Emotes.Providers) == 0 {
+		conf.Emotes.Providers = DefaultEmoteProviders
+	}
+
+	problems = append(problems, validate(&conf, anonymous)...)
+	if len(problems) > 0 {
+		return conf, &configError{file: path, root: &root, env: env, problems: problems}
+	}
+
+	if conf.Names.KeepColors {
+		conf.Names.Contrast = 0
+	}
+	return conf, nil
+}
+
+// validate checks the values that the yaml decoder can't
+func validate(conf *Config, anonymous bool) []problem {
+	var problems []problem
+	add := func(err error, path ...string) {
+		if err != nil {
+			problems = append(problems, problem{path: path, msg: err.Error()})
+		}
+	}
+
+	if !anonymous {
+		problems = append(problems, setAccounts(conf)...)
+	}
+	if port, err := strconv.Atoi(conf.RedirectPort); err != nil || port < 1 || port > 65535 {
+		add(fmt.Errorf("redirectPort %q is not a port", conf.RedirectPort), "redirectPort")
+	}
+	if conf.LineSpacing < 0 {
+		add(fmt.Errorf("lineSpacing can't be negative"), "lineSpacing")
+	}
+	if conf.HistorySize < 0 {
+		add(fmt.Errorf("historySize can't be negative"), "historySize")
+	}
+	if !conf.Names.KeepColors && (conf.Names.Contrast < 1 || conf.Names.Contrast > 21) {
+		add(fmt.Errorf("names contrast %v is not a ratio from 1 to 21", conf.Names.Contrast), "names", "contrast")
+	}
+
+	for i, p := range conf.Emotes.Providers {
+		_, err := emote.NewProvider(p, "", nil)
+		add(err, "emotes", "providers", strconv.Itoa(i))
+	}
+	for i, d := range conf.Moderation.TimeoutPresets {
+		if d <= 0 {
+			add(fmt.Errorf("timeout preset %v is not positive", d), "moderation", "timeoutPresets", strconv.Itoa(i))
+		}
+	}
+
+	switch conf.Layout.Split {
+	case terminal.Single, terminal.Columns, terminal.Rows:
+	default:
+		add(fmt.Errorf("unknown layout split %q, expected %q or %q", conf.Layout.Split, terminal.Columns, terminal.Rows), "layout", "split")
+	}
+	if conf.Layout.Panes != 0 && (conf.Layout.Panes < 2 || conf.Layout.Panes > maxPanes) {
+		add(fmt.Errorf("layout panes %d is not from 2 to %d", conf.Layout.Panes, maxPanes), "layout", "panes")
+	}
+
+	_, err := terminal.NewKeyMap(conf.Keys.Preset, conf.Keys.Bindings)
+	add(err, "keys")
+	_, err = notify.New(nil, false, conf.Notify.Desktop, "")
+	add(err, "notifications", "desktop")
+	_, err = filter.New(conf.Filters)
+	add(err, "filters")
+	_, err = irc.NewHighlights(conf.Highlights)
+	add(err, "highlights")
+	_, err = theme.Load(conf.Theme, false)
+	add(err, "theme")
+
+	return problems
+}
+
+// lineOf returns the line of the deepest key of path found in the yaml document root, or 0
+func lineOf(root *yaml.Node, path []string) int {
+	if root == nil || len(root.Content) == 0 {
+		return 0
+	}
+	n := root.Content[0]
+	line := 0
+	for _, key := range path {
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == key {
+					line = n.Content[i].Line
+					next = n.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(n.Content) {
+				next = n.Content[i]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return line
+}
+
+// applyEnv sets the values of conf named by TTCHAT_* variables in environ, like TTCHAT_USERNAME
+// for username or TTCHAT_LAYOUT_SPLIT for layout.split, and returns the variables by the path they set.
+// Lists are separated by commas. Other TTCHAT_* variables are reported, as they're likely misspelled.
+func applyEnv(conf *Config, environ []string) (map[string]string, []problem) {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, envPrefix) {
+			vars[k] = v
+		}
+	}
+
+	set := make(map[string]string)
+	var problems []problem
+	var walk func(v reflect.Value, path []string)
+	walk = func(v reflect.Value, path []string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			field := v.Field(i)
+			fieldPath := append(append([]string(nil), path...), tag)
+			if field.Kind() == reflect.Struct {
+				walk(field, fieldPath)
+				continue
+			}
+
+			name := envName(fieldPath)
+			value, ok := vars[name]
+			if !ok {
+				continue
+			}
+			delete(vars, name)
+			err := setValue(field, value)
+			if err != nil {
+				problems = append(problems, problem{path: fieldPath, msg: err.Error()})
+			}
+			set[strings.Join(fieldPath, ".")] = name
+		}
+	}
+	walk(reflect.ValueOf(conf).Elem(), nil)
+
+	// TTCHAT_CONFIG names the file instead of a setting
+	delete(vars, envPrefix+"CONFIG")
+	var unknown []string
+	for name := range vars {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		// reported by the variable's name, which can't be the path of a setting
+		set[name] = name
+		problems = append(problems, problem{path: []string{name}, msg: "unknown environment variable, no setting is named by it"})
+	}
+	return set, problems
+}
+
+// envName is the environment variable of a config path, the words of its keys in upper snake case
+func envName(path []string) string {
+	var b strings.Builder
+	b.WriteString(envPrefix)
+	for i, key := range path {
+		if i > 0 {
+			b.WriteString("_")
+		}
+		runes := []rune(key)
+		for j, r := range runes {
+			if j > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[j-1]) || (j+1 < len(runes) && unicode.IsLower(runes[j+1]))) {
+				b.WriteString("_")
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", s)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", s)
+		}
+		v.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		list := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			err := setValue(list.Index(i), p)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("can't be set from the environment")
+	}
+	return nil
+}
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Write and check your configuration",
+	}
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a starter configuration",
+		Long: `
+Ask for your Twitch application's Client ID and your username and write
+a starter configuration to $XDG_CONFIG_HOME/ttchat/config.yaml, or to the
+file given with --config.
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			force, err := cmd.Flags().GetBool("force")
+			if err != nil {
+				errExit(err)
+			}
+
+			path, err := configFlag(cmd)
+			if err != nil {
+				errExit(err)
+			}
+			if path == "" {
+				hd, err := os.UserHomeDir()
+				if err != nil {
+					errExit(err)
+				}
+				path = configPaths(hd, "")[0]
+			}
+
+			err = initConfig(cmd.InOrStdin(), cmd.OutOrStdout(), path, force)
+			if err != nil {
+				errExit(err)
+			}
+		},
+	}
+	initCmd.Flags().Bool("force", false, "replace an existing configuration")
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report every problem of your configuration",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := locateConfig(cmd)
+			if err != nil {
+				errExit(err)
+			}
+
+			_, err = getConfig(path, false)
+			if err != nil {
+				errExit(err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: ok\n", path)
+		},
+	}
+
+	configCmd.AddCommand(initCmd, checkCmd)
+	return configCmd
+}
+
+// configFlag returns the --config flag of the root command
+func configFlag(cmd *cobra.Command) (string, error) {
+	return cmd.Flags().GetString("config")
+}
+
+// locateConfig finds the configuration file for a command
+func locateConfig(cmd *cobra.Command) (string, error) {
+	flag, err := configFlag(cmd)
+	if err != nil {
+		return "", err
+	}
+	hd, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return findConfig(hd, flag)
+}
+
+// initConfig asks for the required settings on in and writes a starter configuration to path
+func initConfig(in io.Reader, out io.Writer, path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to replace it", path)
+	}
+
+	scanner := bufio.NewScanner(in)
+	ask := func(question string, def string) (string, error) {
+		for {
+			if def != "" {
+				fmt.Fprintf(out, "%s [%s]: ", question, def)
+			} else {
+				fmt.Fprintf(out, "%s: ", question)
+			}
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.ErrUnexpectedEOF
+			}
+			answer := strings.TrimSpace(scanner.Text())
+			if answer == "" {
+				answer = def
+			}
+			if answer != "" {
+				return answer, nil
+			}
+		}
+	}
+
+	fmt.Fprintln(out, "Register an application at https://dev.twitch.tv/console with the OAuth Redirect URL http://localhost:<port>.")
+	clientID, err := ask("Client ID", "")
+	if err != nil {
+		return err
+	}
+	username, err := ask("Twitch username", "")
+	if err != nil {
+		return err
+	}
+	port, err := ask("Redirect port", DefaultRedirectPort)
+	if err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(struct {
+		ClientID     string `yaml:"clientID"`
+		Username     string `yaml:"username"`
+		RedirectPort string `yaml:"redirectPort"`
+		LineSpacing  int    `yaml:"lineSpacing"`
+	}{clientID, username, port, 1})
+	if err != nil {
+		return err
+	}
+	b = append([]byte("# See https://github.com/atye/ttchat#setup for every setting.\n"), b...)
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, b, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = getConfig(path, false)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s\n", path)
+	return nil
+}
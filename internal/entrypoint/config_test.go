@@ -0,0 +1,253 @@
+package entrypoint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atye/ttchat/internal/terminal"
+)
+
+func TestGetConfig(t *testing.T) {
+	tests := []struct {
+		Name string
+		yaml string
+		env  map[string]string
+		want string // the error, empty for none
+	}{
+		{
+			Name: "valid",
+			yaml: "clientID: id\nusername: me\n",
+		},
+		{
+			Name: "missing account",
+			yaml: "lineSpacing: 1\n",
+			want: "FILE: no clientID provided\nFILE: no username provided",
+		},
+		{
+			Name: "every problem with its line",
+			yaml: strings.Join([]string{
+				"clientID: id",
+				"username: me",
+				"lineSpacing: lots",
+				"layout:",
+				"  split: grid",
+				"colour: red",
+				"emotes:",
+				"  providers: [bttv, nope]",
+				"keys:",
+				"  bindings:",
+				"    fly: [f]",
+				"",
+			}, "\n"),
+			want: strings.Join([]string{
+				"FILE:3: cannot unmarshal !!str `lots` into int",
+				`FILE:5: unknown layout split "grid", expected "columns" or "rows"`,
+				"FILE:6: field colour not found in type entrypoint.Config",
+				`FILE:8: unknown emote provider "nope"`,
+				`FILE:9: unknown key action "fly", expected one of ban, cancel, clear, copy, delete, down, help, historyNext, historyPrev, inspect, jump, links, nextMention, nextPane, nextTab, prevTab, quit, reply, scrollDown, scrollUp, select, send, timeout, unban, up`,
+			}, "\n"),
+		},
+		{
+			Name: "layout panes",
+			yaml: "clientID: id\nusername: me\nlayout:\n  split: rows\n  panes: 4\n",
+		},
+		{
+			Name: "one pane",
+			yaml: "clientID: id\nusername: me\nlayout:\n  split: rows\n  panes: 1\n",
+			want: "FILE:5: layout panes 1 is not from 2 to 4",
+		},
+		{
+			Name: "too many panes",
+			yaml: "clientID: id\nusername: me\nlayout:\n  panes: 5\n",
+			want: "FILE:4: layout panes 5 is not from 2 to 4",
+		},
+		{
+			Name: "account problems",
+			yaml: "clientID: id\naccounts:\n  bot:\n    channels: [chess]\n",
+			want: `FILE:3: no username provided for account "bot"`,
+		},
+		{
+			Name: "environment",
+			yaml: "clientID: id\n",
+			env:  map[string]string{"TTCHAT_USERNAME": "me", "TTCHAT_LAYOUT_SPLIT": "diagonal"},
+			want: `TTCHAT_LAYOUT_SPLIT: unknown layout split "diagonal", expected "columns" or "rows"`,
+		},
+		{
+			Name: "environment parse error",
+			yaml: "clientID: id\nusername: me\n",
+			env:  map[string]string{"TTCHAT_NO_MOUSE": "maybe"},
+			want: `TTCHAT_NO_MOUSE: "maybe" is not true or false`,
+		},
+		{
+			Name: "unknown environment variables",
+			yaml: "clientID: id\nusername: me\n",
+			env:  map[string]string{"TTCHAT_USER_NAME": "me", "TTCHAT_NOWHISPERS": "true", "TTCHAT_CONFIG": ""},
+			want: strings.Join([]string{
+				"TTCHAT_NOWHISPERS: unknown environment variable, no setting is named by it",
+				"TTCHAT_USER_NAME: unknown environment variable, no setting is named by it",
+			}, "\n"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(test.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := getConfig(path, false)
+			got := ""
+			if err != nil {
+				got = strings.ReplaceAll(err.Error(), path, "FILE")
+			}
+			if got != test.want {
+				t.Errorf("expected error\n%s\ngot\n%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	var conf Config
+	_, problems := applyEnv(&conf, []string{
+		"TTCHAT_CLIENT_ID=id",
+		"TTCHAT_LINE_SPACING=2",
+		"TTCHAT_NO_WHISPERS=true",
+		"TTCHAT_EMOTES_PROVIDERS=bttv, 7tv",
+		"TTCHAT_EMOTES_CACHE_TTL=1h",
+		"TTCHAT_MODERATION_TIMEOUT_PRESETS=1m,10m",
+		"TTCHAT_NAMES_CONTRAST=3",
+		"TTCHAT_LAYOUT_SPLIT=rows",
+		"TTCHAT_THEME_MENTION_BOLD=true",
+		"OTHER=1",
+	})
+	if len(problems) > 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+
+	want := Config{
+		ClientID:    "id",
+		LineSpacing: 2,
+		NoWhispers:  true,
+		Emotes:      EmoteConfig{Providers: []string{"bttv", "7tv"}, CacheTTL: time.Hour},
+		Moderation:  ModConfig{TimeoutPresets: []time.Duration{time.Minute, 10 * time.Minute}},
+		Names:       NameConfig{Contrast: 3},
+		Layout:      LayoutConfig{Split: terminal.Rows},
+	}
+	want.Theme.Mention.Bold = true
+	if !reflect.DeepEqual(conf, want) {
+		t.Errorf("expected config %+v, got %+v", want, conf)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"clientID"}, "TTCHAT_CLIENT_ID"},
+		{[]string{"noMentions"}, "TTCHAT_NO_MENTIONS"},
+		{[]string{"emotes", "cacheTTL"}, "TTCHAT_EMOTES_CACHE_TTL"},
+		{[]string{"emotes", "bttvURL"}, "TTCHAT_EMOTES_BTTV_URL"},
+		{[]string{"theme", "tabActive", "foreground"}, "TTCHAT_THEME_TAB_ACTIVE_FOREGROUND"},
+	}
+
+	for _, test := range tests {
+		if got := envName(test.path); got != test.want {
+			t.Errorf("expected %s, got %s", test.want, got)
+		}
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	hd := t.TempDir()
+	xdg := filepath.Join(hd, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("TTCHAT_CONFIG", "")
+
+	legacy := filepath.Join(hd, ".ttchat", "config.yaml")
+	current := filepath.Join(xdg, "ttchat", "config.yaml")
+
+	_, err := findConfig(hd, "")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+
+	for _, p := range []string{legacy, current} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := findConfig(hd, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != p {
+			t.Errorf("expected %s, got %s", p, got)
+		}
+	}
+
+	flag := filepath.Join(hd, "other.yaml")
+	if got, _ := findConfig(hd, flag); got != flag {
+		t.Errorf("expected %s, got %s", flag, got)
+	}
+}
+
+func TestDataDirs(t *testing.T) {
+	hd := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(hd, "data"))
+	t.Setenv("XDG_STATE_HOME", "relative")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	want := dirs{
+		data:  filepath.Join(hd, "data", "ttchat"),
+		state: filepath.Join(hd, ".local", "state", "ttchat"),
+		cache: filepath.Join(hd, ".cache", "ttchat"),
+	}
+	if got := dataDirs(hd); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	legacy := filepath.Join(hd, ".ttchat")
+	if err := os.Mkdir(legacy, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	want = dirs{data: legacy, state: legacy, cache: filepath.Join(legacy, "cache")}
+	if got := dataDirs(hd); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestInitConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttchat", "config.yaml")
+
+	var out strings.Builder
+	err := initConfig(strings.NewReader("\nid\nme\n\n"), &out, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := getConfig(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.ClientID != "id" || conf.Username != "me" || conf.RedirectPort != DefaultRedirectPort {
+		t.Errorf("expected clientID id, username me and redirectPort %s, got %+v", DefaultRedirectPort, conf)
+	}
+
+	err = initConfig(strings.NewReader("id\nme\n\n"), &out, path, false)
+	if err == nil {
+		t.Errorf("expected an error replacing %s", path)
+	}
+}
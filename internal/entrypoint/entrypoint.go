@@ -2,40 +2,122 @@ package entrypoint
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/atye/ttchat/internal/auth"
-	"github.com/atye/ttchat/internal/auth/openid"
+	"github.com/atye/ttchat/internal/clipboard"
+	"github.com/atye/ttchat/internal/emote"
+	"github.com/atye/ttchat/internal/filter"
+	"github.com/atye/ttchat/internal/history"
+	"github.com/atye/ttchat/internal/ignore"
 	"github.com/atye/ttchat/internal/irc"
 	"github.com/atye/ttchat/internal/irc/client"
+	"github.com/atye/ttchat/internal/notify"
 	"github.com/atye/ttchat/internal/terminal"
+	"github.com/atye/ttchat/internal/theme"
+	"github.com/atye/ttchat/internal/token"
+	"github.com/atye/ttchat/internal/types"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/google/uuid"
+	"github.com/muesli/termenv"
 	"github.com/nicklaw5/helix"
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/twitch"
-	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	ClientID     string `yaml:"clientID"`
-	Username     string `yaml:"username"`
-	RedirectPort string `yaml:"redirectPort"`
-	LineSpacing  int    `yaml:"lineSpacing"`
+	ClientID     string              `yaml:"clientID"`
+	Username     string              `yaml:"username"`
+	RedirectPort string              `yaml:"redirectPort"`
+	LineSpacing  int                 `yaml:"lineSpacing"`
+	Emotes       EmoteConfig         `yaml:"emotes"`
+	NoWhispers   bool                `yaml:"noWhispers"`
+	NoMentions   bool                `yaml:"noMentions"`
+	NoMouse      bool                `yaml:"noMouse"`
+	NoHyperlinks bool                `yaml:"noHyperlinks"`
+	Moderation   ModConfig           `yaml:"moderation"`
+	Ignore       IgnoreConf          `yaml:"ignore"`
+	Filters      []filter.Rule       `yaml:"filters"`
+	Highlights   []irc.HighlightRule `yaml:"highlights"`
+	Notify       NotifyConfig        `yaml:"notifications"`
+	Layout       LayoutConfig        `yaml:"layout"`
+	Theme        theme.Theme         `yaml:"theme"`
+	Timestamps   string              `yaml:"timestamps"`
+	Names        NameConfig          `yaml:"names"`
+	Badges       BadgeConfig         `yaml:"badges"`
+	Keys         KeyConfig           `yaml:"keys"`
+	HistorySize  int                 `yaml:"historySize"`
+	Accounts     map[string]Account  `yaml:"accounts"`
+}
+
+type KeyConfig struct {
+	Preset   string              `yaml:"preset"`
+	Bindings map[string][]string `yaml:"bindings"`
+}
+
+type BadgeConfig struct {
+	Hidden bool                      `yaml:"hidden"`
+	Types  map[string]terminal.Badge `yaml:"types"`
+}
+
+type NameConfig struct {
+	FixedColor bool    `yaml:"fixedColor"`
+	KeepColors bool    `yaml:"keepColors"`
+	Contrast   float64 `yaml:"contrast"`
+}
+
+type LayoutConfig struct {
+	Split terminal.Split `yaml:"split"`
+	Panes int            `yaml:"panes"`
+}
+
+type NotifyConfig struct {
+	Bell    bool   `yaml:"bell"`
+	Desktop string `yaml:"desktop"`
+	Command string `yaml:"command"`
+}
+
+type IgnoreConf struct {
+	Collapse bool `yaml:"collapse"`
+}
+
+type ModConfig struct {
+	Enabled        bool            `yaml:"enabled"`
+	TimeoutPresets []time.Duration `yaml:"timeoutPresets"`
+}
+
+type EmoteConfig struct {
+	Disabled   bool          `yaml:"disabled"`
+	Providers  []string      `yaml:"providers"`
+	CacheTTL   time.Duration `yaml:"cacheTTL"`
+	BTTVURL    string        `yaml:"bttvURL"`
+	FFZURL     string        `yaml:"ffzURL"`
+	SevenTVURL string        `yaml:"sevenTVURL"`
 }
 
 const (
 	DefaultRedirectPort = "9999"
+	DefaultNameContrast = 4.5
+
+	// names are made readable against these when the theme sets no background
+	darkBackground  = "#000000"
+	lightBackground = "#FFFFFF"
+)
+
+var (
+	DefaultEmoteProviders = []string{emote.BTTV, emote.FFZ, emote.SevenTV}
 )
 
 func NewRootCmd() *cobra.Command {
@@ -49,6 +131,8 @@ chat using a small configuration file. See repo for more details.
 ttchat -h
 ttchat --channel GothamChess --channel chessbrah
 ttchat --channel GothamChess --token $TOKEN
+ttchat --channel GothamChess --account bot
+ttchat --channel GothamChess --anonymous
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			rand.Seed(time.Now().UTC().UnixNano())
@@ -69,54 +153,198 @@ ttchat --channel GothamChess --token $TOKEN
 				errExit(err)
 			}
 
+			accountName, err := cmd.Flags().GetString("account")
+			if err != nil {
+				errExit(err)
+			}
+
+			anonymous, err := cmd.Flags().GetBool("anonymous")
+			if err != nil {
+				errExit(err)
+			}
+
 			hd, err := os.UserHomeDir()
 			if err != nil {
 				errExit(err)
 			}
+			local := dataDirs(hd)
+
+			path, err := locateConfig(cmd)
+			if err != nil && !(anonymous && errors.Is(err, os.ErrNotExist)) {
+				errExit(err)
+			}
 
-			conf, err := getConfig(hd)
+			conf, err := getConfig(path, anonymous)
 			if err != nil {
 				errExit(err)
 			}
 
-			if accessToken == "" {
-				provider, err := oidc.NewProvider(context.Background(), "https://id.twitch.tv/oauth2")
+			t, err := loadTheme(conf.Theme)
+			if err != nil {
+				errExit(err)
+			}
+			styles := irc.NewStyles(t)
+			styles.HashNames = !conf.Names.FixedColor
+			styles.MinContrast = conf.Names.Contrast
+			if styles.Background == "" {
+				// lipgloss asks the terminal once and reuses the answer for adaptive colors
+				styles.Background = lightBackground
+				if lipgloss.HasDarkBackground() {
+					styles.Background = darkBackground
+				}
+			}
+
+			// anonymous users have no session
+			var main *session
+			sessions := make(map[string]*session)
+			accounts := make(map[string]string)
+			if !anonymous {
+				mainAccount, err := pickAccount(conf, accountName)
+				if err != nil {
+					errExit(err)
+				}
+
+				accounts, err = channelAccounts(conf, channels, mainAccount)
 				if err != nil {
 					errExit(err)
 				}
-				oidcVerifier := openid.CoreOSVerifier{Verifier: provider.Verifier(&oidc.Config{ClientID: conf.ClientID})}
 
-				accessToken, err = getAccessToken(logger, conf, oidcVerifier)
+				// log in to the main account first, the only one the --token flag is for
+				store := token.NewStore(filepath.Join(local.state, "tokens"))
+				// only the main account whispers
+				main, err = login(logger, conf, mainAccount, accessToken, store, !conf.NoWhispers)
 				if err != nil {
 					errExit(err)
 				}
+				sessions[mainAccount] = main
+				for _, c := range channels {
+					name := accounts[c]
+					if _, ok := sessions[name]; ok {
+						continue
+					}
+					sessions[name], err = login(logger, conf, name, "", store, false)
+					if err != nil {
+						errExit(err)
+					}
+				}
+			}
 
-				err = auth.ValidateAccessToken(accessToken)
+			emoteSets := make(map[string]*emote.Set)
+			for _, c := range channels {
+				emoteSets[c] = emote.NewSet()
+			}
+			if !conf.Emotes.Disabled {
+				loader, err := getEmoteLoader(logger, conf, local.cache)
 				if err != nil {
 					errExit(err)
 				}
+
+				var channelIDs map[string]string
+				if main != nil {
+					channelIDs, err = getChannelIDs(channels, main.helix)
+					if err != nil {
+						logger.Printf("getting channel ids: %v\n", err)
+					}
+				}
+
+				go loadEmotes(loader, channelIDs, emoteSets)
+			}
+
+			ignoreList, err := ignore.Load(filepath.Join(local.data, "ignore.yaml"))
+			if err != nil {
+				errExit(err)
+			}
+
+			sent, err := history.Load(filepath.Join(local.state, "history.yaml"), conf.HistorySize)
+			if err != nil {
+				errExit(err)
 			}
 
-			tc, err := helix.NewClient(&helix.Options{
-				ClientID:        conf.ClientID,
-				UserAccessToken: accessToken,
-			})
+			filters, err := filter.New(conf.Filters)
 			if err != nil {
 				errExit(err)
 			}
 
-			displayName, err := getUserDisplayName(conf, accessToken, tc)
+			highlights, err := irc.NewHighlights(conf.Highlights)
 			if err != nil {
 				errExit(err)
 			}
 
+			var channelOpts []terminal.ChannelOption
+			if conf.Timestamps != "" {
+				channelOpts = append(channelOpts, terminal.WithTimestamps(conf.Timestamps))
+			}
+			if !conf.Badges.Hidden {
+				channelOpts = append(channelOpts, terminal.WithBadges(terminal.MergeBadges(conf.Badges.Types)))
+			}
+			if !conf.NoHyperlinks {
+				channelOpts = append(channelOpts, terminal.WithHyperlinks())
+			}
+
 			var channelModels []*terminal.Channel
 			for _, c := range channels {
-				conn := irc.NewTwitch(client.NewGempirClient(conf.Username, c, accessToken), logger, displayName, c)
-				channelModels = append(channelModels, terminal.NewChannel(conn, c, conf.LineSpacing))
+				opts := []irc.Option{irc.WithEmotes(emoteSets[c]), irc.WithIgnore(ignoreList, conf.Ignore.Collapse), irc.WithFilters(filters), irc.WithHighlights(highlights), irc.WithStyles(styles)}
+				if anonymous {
+					conn := irc.NewTwitch(client.NewGempirAnonymousClient(c), logger, "", c, opts...)
+					channelModels = append(channelModels, terminal.NewChannel(conn, c, conf.LineSpacing, channelOpts...))
+					continue
+				}
+
+				s := sessions[accounts[c]]
+				if conf.Moderation.Enabled {
+					opts = append(opts, irc.WithModeration(s.api))
+				}
+
+				conn := irc.NewTwitch(client.NewGempirClient(s.account.Username, c, s.accessToken), logger, s.displayName, c, opts...)
+				channelModels = append(channelModels, terminal.NewChannel(conn, c, conf.LineSpacing, append(channelOpts, terminal.WithCompleter(emoteSets[c]))...))
+			}
+
+			for _, tab := range filters.Routes() {
+				if !containsChannel(channels, tab) {
+					channelModels = append(channelModels, terminal.NewChannel(irc.NewTab(tab, styles), tab, conf.LineSpacing, channelOpts...))
+				}
+			}
+
+			if !anonymous && main.whispers {
+				whispers := irc.NewWhispers(client.NewGempirWhisperClient(main.account.Username, main.accessToken), main.api, logger, main.displayName, styles)
+				channelModels = append(channelModels, terminal.NewChannel(whispers, irc.WhispersChannel, conf.LineSpacing, append(channelOpts, terminal.WithGrouping(whisperConversation))...))
 			}
 
-			if tea.NewProgram(terminal.NewModel(logger, channelModels...), tea.WithAltScreen()).Start() != nil {
+			keys, err := terminal.NewKeyMap(conf.Keys.Preset, conf.Keys.Bindings)
+			if err != nil {
+				errExit(err)
+			}
+
+			modelOpts := []terminal.ModelOption{
+				terminal.WithKeyMap(keys),
+				terminal.WithTheme(t),
+				terminal.WithHistory(sent),
+				terminal.WithTimeoutPresets(conf.Moderation.TimeoutPresets),
+				terminal.WithIgnoreList(ignoreList),
+				terminal.WithClipboard(clipboard.New()),
+			}
+			if anonymous {
+				modelOpts = append(modelOpts, terminal.WithReadOnly())
+			} else {
+				modelOpts = append(modelOpts, terminal.WithUserLookup(main.api))
+			}
+			if !conf.NoMentions {
+				modelOpts = append(modelOpts, terminal.WithMentions(conf.LineSpacing, channelOpts...))
+			}
+			if conf.Notify.Bell || conf.Notify.Desktop != "" || conf.Notify.Command != "" {
+				notifier, err := notify.New(logger, conf.Notify.Bell, conf.Notify.Desktop, conf.Notify.Command)
+				if err != nil {
+					errExit(err)
+				}
+				modelOpts = append(modelOpts, terminal.WithNotifier(notifier))
+			}
+			modelOpts = append(modelOpts, terminal.WithLayout(conf.Layout.Split, conf.Layout.Panes))
+
+			programOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithReportFocus()}
+			if !conf.NoMouse {
+				programOpts = append(programOpts, tea.WithMouseCellMotion())
+			}
+			if tea.NewProgram(terminal.NewModel(logger, channelModels, modelOpts...), programOpts...).Start() != nil {
 				errExit(err)
 			}
 		},
@@ -128,46 +356,37 @@ ttchat --channel GothamChess --token $TOKEN
 		errExit(err)
 	}
 
-	rootCmd.Flags().StringP("token", "t", "", `provide your own oauth access token to bypass browser login (must have chat:read and chat:edit scopes)`)
+	rootCmd.Flags().StringP("token", "t", "", `provide your own oauth access token to bypass browser login (must have the chat:read and chat:edit scopes, whispers:read and user:manage:whispers for whispers, and moderator:manage:banned_users, moderator:manage:chat_messages and moderator:read:followers with moderation enabled)`)
+	rootCmd.Flags().Bool("anonymous", false, "read chat without logging in or a configuration file")
+	rootCmd.Flags().StringP("account", "a", "", `the configured account to chat as in channels no account lists, and to whisper and look up users with`)
+
+	rootCmd.PersistentFlags().String("config", "", "the configuration file (default $XDG_CONFIG_HOME/ttchat/config.yaml or $HOME/.ttchat/config.yaml)")
+
+	rootCmd.AddCommand(newFiltersCmd(), newConfigCmd())
 	return rootCmd
 }
 
-func getConfig(hd string) (Config, error) {
-	f, err := os.ReadFile(filepath.Join(hd, ".ttchat", "config.yaml"))
-	if err != nil {
-		return Config{}, err
-	}
-
-	var conf Config
-	err = yaml.Unmarshal(f, &conf)
+// loadTheme resolves the configured theme, turning off colors for the none preset
+func loadTheme(conf theme.Theme) (theme.Theme, error) {
+	t, err := theme.Load(conf, os.Getenv("NO_COLOR") != "")
 	if err != nil {
-		return Config{}, err
-	}
-
-	if conf.ClientID == "" {
-		return Config{}, fmt.Errorf("no clientID provided")
-	}
-
-	if conf.Username == "" {
-		return Config{}, fmt.Errorf("no username provided")
+		return theme.Theme{}, err
 	}
-
-	if conf.RedirectPort == "" {
-		conf.RedirectPort = DefaultRedirectPort
+	if t.Preset == theme.NoColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
 	}
-
-	return conf, nil
+	return t, nil
 }
 
-func getAccessToken(logger *log.Logger, conf Config, verifier auth.TokenVerifyier) (string, error) {
+func getAccessToken(logger *log.Logger, account Account, scopes []string, verifier auth.TokenVerifyier) (string, error) {
 	oauthConf := &oauth2.Config{
-		ClientID: conf.ClientID,
-		Scopes:   []string{"openid", "chat:read", "chat:edit"},
+		ClientID: account.ClientID,
+		Scopes:   scopes,
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  twitch.Endpoint.AuthURL,
 			TokenURL: twitch.Endpoint.TokenURL,
 		},
-		RedirectURL: fmt.Sprintf("http://localhost:%s", conf.RedirectPort),
+		RedirectURL: fmt.Sprintf("http://localhost:%s", account.RedirectPort),
 	}
 
 	f := func() (string, error) {
@@ -190,12 +409,35 @@ func getAccessToken(logger *log.Logger, conf Config, verifier auth.TokenVerifyie
 	return t, nil
 }
 
+var (
+	whisperScopes = []string{"whispers:read", "user:manage:whispers"}
+)
+
+// scopes returns the scopes to log in with, the whisper scopes only for the account that whispers
+func scopes(conf Config, whispers bool) []string {
+	s := []string{"openid", "chat:read", "chat:edit"}
+	if whispers {
+		s = append(s, whisperScopes...)
+	}
+	if conf.Moderation.Enabled {
+		s = append(s, "moderator:manage:banned_users", "moderator:manage:chat_messages", "moderator:read:followers")
+	}
+	return s
+}
+
+func whisperConversation(msg types.Message) string {
+	if w, ok := msg.(types.WhisperMessage); ok {
+		return w.Conversation
+	}
+	return ""
+}
+
 type twitchAPI interface {
 	GetUsers(params *helix.UsersParams) (*helix.UsersResponse, error)
 }
 
-func getUserDisplayName(conf Config, accessToken string, api twitchAPI) (string, error) {
-	resp, err := api.GetUsers(&helix.UsersParams{Logins: []string{conf.Username}})
+func getUserDisplayName(username string, api twitchAPI) (string, error) {
+	resp, err := api.GetUsers(&helix.UsersParams{Logins: []string{username}})
 	if err != nil {
 		return "", err
 	}
@@ -203,7 +445,7 @@ func getUserDisplayName(conf Config, accessToken string, api twitchAPI) (string,
 		return "", fmt.Errorf(resp.ErrorMessage)
 	}
 
-	displayName := conf.Username
+	displayName := username
 	if len(resp.Data.Users) >= 1 {
 		if n := resp.Data.Users[0].DisplayName; n != "" {
 			displayName = n
@@ -212,6 +454,74 @@ func getUserDisplayName(conf Config, accessToken string, api twitchAPI) (string,
 	return displayName, nil
 }
 
+func getChannelIDs(channels []string, api twitchAPI) (map[string]string, error) {
+	logins := make([]string, len(channels))
+	for i, c := range channels {
+		logins[i] = strings.ToLower(c)
+	}
+
+	resp, err := api.GetUsers(&helix.UsersParams{Logins: logins})
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorMessage != "" {
+		return nil, fmt.Errorf(resp.ErrorMessage)
+	}
+
+	ids := make(map[string]string)
+	for _, u := range resp.Data.Users {
+		for _, c := range channels {
+			if strings.EqualFold(u.Login, c) {
+				ids[c] = u.ID
+			}
+		}
+	}
+	return ids, nil
+}
+
+func getEmoteLoader(logger *log.Logger, conf Config, cacheDir string) (emote.Loader, error) {
+	urls := map[string]string{
+		emote.BTTV:    conf.Emotes.BTTVURL,
+		emote.FFZ:     conf.Emotes.FFZURL,
+		emote.SevenTV: conf.Emotes.SevenTVURL,
+	}
+
+	var providers []emote.Provider
+	for _, name := range conf.Emotes.Providers {
+		p, err := emote.NewProvider(name, urls[strings.ToLower(name)], nil)
+		if err != nil {
+			return emote.Loader{}, err
+		}
+		providers = append(providers, p)
+	}
+
+	return emote.Loader{
+		Providers: providers,
+		Cache:     emote.NewCache(filepath.Join(cacheDir, "emotes"), conf.Emotes.CacheTTL),
+		Log:       logger,
+	}, nil
+}
+
+func loadEmotes(loader emote.Loader, channelIDs map[string]string, sets map[string]*emote.Set) {
+	ctx := context.Background()
+
+	global := emote.NewSet()
+	loader.LoadGlobal(ctx, global)
+	for c, set := range sets {
+		set.Merge(global)
+		loader.LoadChannel(ctx, channelIDs[c], set)
+	}
+}
+
+func containsChannel(channels []string, name string) bool {
+	for _, c := range channels {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
 func errExit(err error) {
 	fmt.Fprintf(os.Stderr, "%v\n", err)
 	os.Exit(1)
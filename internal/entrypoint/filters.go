@@ -0,0 +1,146 @@
+package entrypoint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atye/ttchat/internal/filter"
+	"github.com/atye/ttchat/internal/irc/client"
+	"github.com/atye/ttchat/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func newFiltersCmd() *cobra.Command {
+	filtersCmd := &cobra.Command{
+		Use:   "filters",
+		Short: "Work with the message filters in your configuration",
+	}
+
+	testCmd := &cobra.Command{
+		Use:   "test <log file>",
+		Short: "Run the configured filters against a chat log",
+		Long: `
+Run the filters from your configuration against a chat log and print the
+action taken for every message. Each line of the log is either a raw Twitch
+IRC PRIVMSG line or a "user: message" line, optionally prefixed by "#channel ".
+
+ttchat filters test chat.log
+ttchat filters test --channel GothamChess chat.log
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			channel, err := cmd.Flags().GetString("channel")
+			if err != nil {
+				errExit(err)
+			}
+
+			path, err := locateConfig(cmd)
+			if err != nil {
+				errExit(err)
+			}
+
+			conf, err := getConfig(path, false)
+			if err != nil {
+				errExit(err)
+			}
+
+			engine, err := filter.New(conf.Filters)
+			if err != nil {
+				errExit(err)
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				errExit(err)
+			}
+			defer f.Close()
+
+			err = testFilters(cmd.OutOrStdout(), engine, f, channel)
+			if err != nil {
+				errExit(err)
+			}
+		},
+	}
+	testCmd.Flags().StringP("channel", "c", "", "channel of log lines that don't name one")
+
+	filtersCmd.AddCommand(testCmd)
+	return filtersCmd
+}
+
+func testFilters(w io.Writer, engine *filter.Engine, log io.Reader, channel string) error {
+	counts := make(map[filter.Action]int)
+	total := 0
+
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		msg, ok := parseLogLine(scanner.Text(), channel)
+		if !ok {
+			continue
+		}
+		total++
+
+		action := "-"
+		if rule, matched := engine.Match(msg.Channel, msg); matched {
+			counts[rule.Action]++
+			action = fmt.Sprintf("%s (%s)", rule.Action, rule.Name)
+			if rule.Action == filter.Route {
+				action = fmt.Sprintf("route to %s (%s)", rule.Tab, rule.Name)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s: %s\n", n, action, msg.Name, msg.Text)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	matched := 0
+	var summary []string
+	for _, a := range []filter.Action{filter.Hide, filter.Dim, filter.Highlight, filter.Route} {
+		if counts[a] > 0 {
+			matched += counts[a]
+			summary = append(summary, fmt.Sprintf("%s %d", a, counts[a]))
+		}
+	}
+	fmt.Fprintf(w, "\n%d messages, %d matched", total, matched)
+	if len(summary) > 0 {
+		fmt.Fprintf(w, ": %s", strings.Join(summary, ", "))
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// parseLogLine reads a raw IRC line or a "[#channel ]user: message" line
+func parseLogLine(line string, channel string) (types.PrivateMessage, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return types.PrivateMessage{}, false
+	}
+
+	if strings.HasPrefix(line, "@") || strings.HasPrefix(line, ":") {
+		return client.ParseLine(line)
+	}
+
+	if strings.HasPrefix(line, "#") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return types.PrivateMessage{}, false
+		}
+		channel = strings.TrimPrefix(fields[0], "#")
+		line = fields[1]
+	}
+
+	user, text, ok := strings.Cut(line, ": ")
+	if !ok || user == "" {
+		return types.PrivateMessage{}, false
+	}
+	return types.PrivateMessage{
+		Channel: channel,
+		Login:   strings.ToLower(user),
+		Name:    user,
+		Text:    text,
+	}, true
+}
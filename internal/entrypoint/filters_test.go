@@ -0,0 +1,48 @@
+package entrypoint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/atye/ttchat/internal/filter"
+)
+
+func TestTestFilters(t *testing.T) {
+	engine, err := filter.New([]filter.Rule{
+		{Name: "commands", Text: `^!`, Action: filter.Hide},
+		{Name: "bots", User: `bot$`, Action: filter.Route, Tab: "Bots"},
+		{Name: "mods", Badges: []string{"moderator"}, Action: filter.Highlight, Channels: []string{"chess"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := strings.Join([]string{
+		"foo: !drop",
+		"",
+		"nightbot: follow the channel",
+		"#other bar: hello",
+		"@badges=moderator/1;display-name=Mod;id=1;user-id=2 :mod!mod@mod.tmi.twitch.tv PRIVMSG #chess :be nice",
+		"not a message",
+	}, "\n")
+
+	var out bytes.Buffer
+	err = testFilters(&out, engine, strings.NewReader(log), "chess")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"1\thide (commands)\tfoo: !drop",
+		"3\troute to Bots (bots)\tnightbot: follow the channel",
+		"4\t-\tbar: hello",
+		"5\thighlight (mods)\tMod: be nice",
+		"",
+		"4 messages, 3 matched: hide 1, highlight 1, route 1",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("expected output\n%s\ngot\n%s", want, out.String())
+	}
+}
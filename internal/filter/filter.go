@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/atye/ttchat/internal/types"
+)
+
+type Action string
+
+const (
+	Hide      Action = "hide"
+	Dim       Action = "dim"
+	Highlight Action = "highlight"
+	Route     Action = "route"
+)
+
+// Rule matches a message when every condition it sets matches
+type Rule struct {
+	Name      string   `yaml:"name"`
+	Channels  []string `yaml:"channels"`  // only apply in these channels
+	Text      string   `yaml:"text"`      // regular expression on the message text
+	User      string   `yaml:"user"`      // regular expression on the login or display name
+	Badges    []string `yaml:"badges"`    // the user has any of these badges
+	MinLength int      `yaml:"minLength"` // the text has at least this many characters
+	MaxLength int      `yaml:"maxLength"` // the text has at most this many characters
+	EmoteOnly *bool    `yaml:"emoteOnly"` // the message is, or isn't, only emotes
+	Links     *bool    `yaml:"links"`     // the message does, or doesn't, contain a link
+	Action    Action   `yaml:"action"`
+	Tab       string   `yaml:"tab"`   // destination of the route action
+	Color     string   `yaml:"color"` // background of the highlight action
+}
+
+// Engine matches messages against rules in order
+type Engine struct {
+	rules []rule
+}
+
+type rule struct {
+	Rule
+	text *regexp.Regexp
+	user *regexp.Regexp
+}
+
+var (
+	linkRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|tv|gg|io|ly|me|co|be|app|dev)(?:/\S*)?\b`)
+)
+
+// New compiles rules, reporting every invalid rule
+func New(rules []Rule) (*Engine, error) {
+	var errs []string
+	e := &Engine{}
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		c := rule{Rule: r}
+		var err error
+		if r.Text != "" {
+			c.text, err = regexp.Compile(r.Text)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("filter %s: text: %v", name, err))
+			}
+		}
+		if r.User != "" {
+			c.user, err = regexp.Compile(r.User)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("filter %s: user: %v", name, err))
+			}
+		}
+
+		switch r.Action {
+		case Hide, Dim, Highlight:
+		case Route:
+			if r.Tab == "" {
+				errs = append(errs, fmt.Sprintf("filter %s: route needs a tab", name))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("filter %s: unknown action %q (want hide, dim, highlight or route)", name, r.Action))
+		}
+
+		c.Name = name
+		e.rules = append(e.rules, c)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return e, nil
+}
+
+// Match returns the first rule matching msg in channel. msg must not be styled yet.
+func (e *Engine) Match(channel string, msg types.PrivateMessage) (Rule, bool) {
+	if e == nil {
+		return Rule{}, false
+	}
+	for _, r := range e.rules {
+		if r.matches(channel, msg) {
+			return r.Rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Routes returns the tabs that rules route messages to
+func (e *Engine) Routes() []string {
+	if e == nil {
+		return nil
+	}
+	var tabs []string
+	seen := make(map[string]bool)
+	for _, r := range e.rules {
+		if r.Action == Route && !seen[r.Tab] {
+			seen[r.Tab] = true
+			tabs = append(tabs, r.Tab)
+		}
+	}
+	return tabs
+}
+
+func (r rule) matches(channel string, msg types.PrivateMessage) bool {
+	if len(r.Channels) > 0 && !containsFold(r.Channels, strings.TrimPrefix(channel, "#")) {
+		return false
+	}
+	if r.text != nil && !r.text.MatchString(msg.Text) {
+		return false
+	}
+	if r.user != nil && !r.user.MatchString(msg.Login) && !r.user.MatchString(msg.Name) {
+		return false
+	}
+	if len(r.Badges) > 0 && !hasBadge(msg.Badges, r.Badges) {
+		return false
+	}
+	length := utf8.RuneCountInString(msg.Text)
+	if r.MinLength > 0 && length < r.MinLength {
+		return false
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return false
+	}
+	if r.EmoteOnly != nil && *r.EmoteOnly != msg.EmoteOnly {
+		return false
+	}
+	if r.Links != nil && *r.Links != linkRegexp.MatchString(msg.Text) {
+		return false
+	}
+	return true
+}
+
+func hasBadge(badges map[string]int, want []string) bool {
+	for _, b := range want {
+		if _, ok := badges[strings.ToLower(b)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(strings.TrimPrefix(s, "#"), v) {
+			return true
+		}
+	}
+	return false
+}
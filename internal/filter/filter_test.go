@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atye/ttchat/internal/types"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestMatch(t *testing.T) {
+	rules := []Rule{
+		{Name: "links", Links: boolPtr(true), Badges: nil, Action: Hide, Channels: []string{"#chess"}},
+		{Name: "mods", Badges: []string{"moderator", "broadcaster"}, Action: Highlight},
+		{Name: "bots", User: `(?i)bot$`, Action: Route, Tab: "Bots"},
+		{Name: "spam", Text: `(?i)^!`, MaxLength: 10, Action: Dim},
+		{Name: "long", MinLength: 20, Action: Dim},
+		{Name: "emotes", EmoteOnly: boolPtr(true), Action: Hide},
+	}
+
+	e, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name     string
+		channel  string
+		msg      types.PrivateMessage
+		wantRule string
+	}{
+		{"link", "chess", types.PrivateMessage{Login: "foo", Text: "go to example.com now"}, "links"},
+		{"url", "chess", types.PrivateMessage{Login: "foo", Text: "https://x.y/z"}, "links"},
+		{"link in other channel", "other", types.PrivateMessage{Login: "foo", Text: "hi www.a.b"}, ""},
+		{"badge", "other", types.PrivateMessage{Login: "foo", Text: "hi", Badges: map[string]int{"moderator": 1}}, "mods"},
+		{"user by login", "other", types.PrivateMessage{Login: "nightbot", Text: "hi"}, "bots"},
+		{"user by display name", "other", types.PrivateMessage{Login: "x", Name: "SuperBot", Text: "hi"}, "bots"},
+		{"text and max length", "other", types.PrivateMessage{Login: "foo", Text: "!drop"}, "spam"},
+		{"text too long", "other", types.PrivateMessage{Login: "foo", Text: "!commands please"}, ""},
+		{"min length", "other", types.PrivateMessage{Login: "foo", Text: strings.Repeat("a", 20)}, "long"},
+		{"emote only", "other", types.PrivateMessage{Login: "foo", Text: "Kappa", EmoteOnly: true}, "emotes"},
+		{"no match", "other", types.PrivateMessage{Login: "foo", Text: "hello"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			rule, ok := e.Match(test.channel, test.msg)
+			if test.wantRule == "" {
+				if ok {
+					t.Errorf("expected no match, got %s", rule.Name)
+				}
+				return
+			}
+			if !ok || rule.Name != test.wantRule {
+				t.Errorf("expected rule %s, got %s", test.wantRule, rule.Name)
+			}
+		})
+	}
+
+	if got := e.Routes(); len(got) != 1 || got[0] != "Bots" {
+		t.Errorf("expected routes [Bots], got %v", got)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New([]Rule{
+		{Name: "bad text", Text: "(", Action: Hide},
+		{Name: "bad user", User: "[", Action: Hide},
+		{Name: "no tab", Action: Route},
+		{Action: "explode"},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, want := range []string{"filter bad text: text:", "filter bad user: user:", "filter no tab: route needs a tab", `filter #4: unknown action "explode"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestNilEngine(t *testing.T) {
+	var e *Engine
+	if _, ok := e.Match("chess", types.PrivateMessage{Text: "hi"}); ok {
+		t.Error("expected no match from a nil engine")
+	}
+}
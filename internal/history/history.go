@@ -0,0 +1,92 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultSize = 100
+)
+
+// History is the messages sent in each channel, persisted to a yaml file
+type History struct {
+	mu      sync.RWMutex
+	saving  sync.Mutex // saves write the file one at a time
+	path    string
+	size    int
+	entries map[string][]string
+}
+
+// Load reads the history at path, keeping up to size messages per channel. A missing file is an empty history.
+func Load(path string, size int) (*History, error) {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	h := &History{path: path, size: size, entries: make(map[string][]string)}
+
+	f, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(f, &h.entries)
+	if err != nil {
+		return nil, err
+	}
+	if h.entries == nil {
+		h.entries = make(map[string][]string)
+	}
+	return h, nil
+}
+
+// Add appends text to the history of channel, unless it repeats the last message. Save persists it.
+func (h *History) Add(channel string, text string) {
+	channel = strings.ToLower(channel)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := h.entries[channel]
+	if len(entries) > 0 && entries[len(entries)-1] == text {
+		return
+	}
+	entries = append(entries, text)
+	if len(entries) > h.size {
+		entries = entries[len(entries)-h.size:]
+	}
+	h.entries[channel] = entries
+}
+
+// Entries returns the messages sent in channel, oldest first
+func (h *History) Entries(channel string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]string(nil), h.entries[strings.ToLower(channel)]...)
+}
+
+// Save writes the history to its file
+func (h *History) Save() error {
+	h.saving.Lock()
+	defer h.saving.Unlock()
+
+	h.mu.RLock()
+	b, err := yaml.Marshal(h.entries)
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(h.path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, b, 0o600)
+}
@@ -0,0 +1,42 @@
+package history
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.yaml")
+	h, err := Load(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"one", "two", "two", "three", "four"} {
+		h.Add("Chess", text)
+	}
+	h.Add("other", "hi")
+	if err := h.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"two", "three", "four"}
+	if got := h.Entries("chess"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected entries %v, got %v", want, got)
+	}
+
+	reloaded, err := Load(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Entries("chess"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected reloaded entries %v, got %v", want, got)
+	}
+	if got := reloaded.Entries("other"); !reflect.DeepEqual(got, []string{"hi"}) {
+		t.Errorf("expected reloaded entries %v, got %v", []string{"hi"}, got)
+	}
+	if got := reloaded.Entries("missing"); len(got) != 0 {
+		t.Errorf("expected no entries, got %v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package ignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry ignores a user by login or user ID, in Channel or everywhere when Channel is empty
+type Entry struct {
+	Login   string `yaml:"login,omitempty"`
+	UserID  string `yaml:"userID,omitempty"`
+	Channel string `yaml:"channel,omitempty"`
+}
+
+// List is an ignore list persisted to a yaml file
+type List struct {
+	mu      sync.RWMutex
+	path    string
+	entries []Entry
+}
+
+// Load reads the ignore list at path. A missing file is an empty list.
+func Load(path string) (*List, error) {
+	l := &List{path: path}
+
+	f, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(f, &l.entries)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Ignored reports whether messages from the user with login or userID are ignored in channel.
+// A user ignored by login is remembered by user ID too so the entry survives a rename.
+func (l *List) Ignored(channel string, login string, userID string) bool {
+	l.mu.RLock()
+	i := l.find(channel, login, userID)
+	learn := i >= 0 && userID != "" && l.entries[i].UserID == ""
+	l.mu.RUnlock()
+
+	if learn {
+		l.mu.Lock()
+		if j := l.find(channel, login, ""); j >= 0 && l.entries[j].UserID == "" {
+			l.entries[j].UserID = userID
+			// a failed save only loses the learned ID
+			_ = l.save()
+		}
+		l.mu.Unlock()
+	}
+	return i >= 0
+}
+
+func (l *List) find(channel string, login string, userID string) int {
+	for i, e := range l.entries {
+		if e.Channel != "" && !strings.EqualFold(e.Channel, channel) {
+			continue
+		}
+		if (login != "" && strings.EqualFold(e.Login, login)) || (userID != "" && e.UserID == userID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Ignore adds login, with its userID when known, to the list for channel, or for every channel when channel is empty
+func (l *List) Ignore(login string, userID string, channel string) error {
+	login = normalize(login)
+	channel = normalize(channel)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, e := range l.entries {
+		if e.Channel == channel && e.matches(login, userID) {
+			if e.UserID != "" || userID == "" {
+				return nil
+			}
+			l.entries[i].UserID = userID
+			return l.save()
+		}
+	}
+	l.entries = append(l.entries, Entry{Login: login, UserID: userID, Channel: channel})
+	return l.save()
+}
+
+// Unignore removes the entries of login, or of userID after a rename, for channel,
+// or every entry of the user when channel is empty
+func (l *List) Unignore(login string, userID string, channel string) error {
+	login = normalize(login)
+	channel = normalize(channel)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var kept []Entry
+	for _, e := range l.entries {
+		if e.matches(login, userID) && (channel == "" || e.Channel == channel) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) == len(l.entries) {
+		return nil
+	}
+	l.entries = kept
+	return l.save()
+}
+
+func (e Entry) matches(login string, userID string) bool {
+	return e.Login == login || (userID != "" && e.UserID == userID)
+}
+
+func (l *List) Entries() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+func (l *List) save() error {
+	b, err := yaml.Marshal(l.entries)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(l.path), 0o755)
+	if err != nil {
+		return err
+	}
+	// who the user ignores is private, also in lists written readable by others before
+	err = os.WriteFile(l.path, b, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(l.path, 0o600)
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimLeft(s, "@#"))
+}
@@ -0,0 +1,145 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.yaml")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Ignore("@SpamBot", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Ignore("chatter", "", "#Chess"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name    string
+		channel string
+		login   string
+		userID  string
+		want    bool
+	}{
+		{"everywhere", "foo", "spambot", "", true},
+		{"everywhere mixed case", "foo", "SpamBot", "", true},
+		{"channel", "chess", "chatter", "", true},
+		{"other channel", "foo", "chatter", "", false},
+		{"not ignored", "chess", "friend", "", false},
+		{"prefix is not a match", "chess", "spambot2", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := l.Ignored(test.channel, test.login, test.userID); got != test.want {
+				t.Errorf("expected ignored %t, got %t", test.want, got)
+			}
+		})
+	}
+
+	t.Run("learns user id", func(t *testing.T) {
+		if !l.Ignored("foo", "spambot", "123") {
+			t.Fatal("expected spambot ignored")
+		}
+		if !l.Ignored("foo", "renamedbot", "123") {
+			t.Error("expected renamed user ignored by user id")
+		}
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		reloaded, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Entry{{Login: "spambot", UserID: "123"}, {Login: "chatter", Channel: "chess"}}
+		if got := reloaded.Entries(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected entries %v, got %v", want, got)
+		}
+	})
+
+	t.Run("unignore", func(t *testing.T) {
+		if err := l.Unignore("chatter", "", "other"); err != nil {
+			t.Fatal(err)
+		}
+		if !l.Ignored("chess", "chatter", "") {
+			t.Error("expected chatter still ignored in chess")
+		}
+
+		if err := l.Unignore("chatter", "", ""); err != nil {
+			t.Fatal(err)
+		}
+		if l.Ignored("chess", "chatter", "") {
+			t.Error("expected chatter unignored")
+		}
+	})
+}
+
+func TestRenamed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.yaml")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Ignore("oldname", "123", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Ignore("newname", "123", ""); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Entry{{Login: "oldname", UserID: "123"}}; !reflect.DeepEqual(l.Entries(), want) {
+		t.Errorf("expected entries %v, got %v", want, l.Entries())
+	}
+	if !l.Ignored("chess", "newname", "123") {
+		t.Error("expected the renamed user ignored")
+	}
+
+	if err := l.Unignore("newname", "123", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Entries()) != 0 {
+		t.Errorf("expected the renamed user unignored, got %v", l.Entries())
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode %v, got %v", os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestLearnUserID(t *testing.T) {
+	l, err := Load(filepath.Join(t.TempDir(), "ignore.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Ignore("spambot", "", "chess"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Ignore("SpamBot", "123", "#chess"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Entry{{Login: "spambot", UserID: "123", Channel: "chess"}}; !reflect.DeepEqual(l.Entries(), want) {
+		t.Errorf("expected entries %v, got %v", want, l.Entries())
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	l, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Entries()) != 0 {
+		t.Errorf("expected no entries, got %v", l.Entries())
+	}
+}
@@ -1,18 +1,29 @@
 package client
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/atye/ttchat/internal/irc"
 	"github.com/atye/ttchat/internal/types"
-	"github.com/gempir/go-twitch-irc/v2"
+	"github.com/gempir/go-twitch-irc/v4"
 )
 
 type Gempir struct {
-	irc *twitch.Client
+	irc       *twitch.Client
+	anonymous bool // read-only, Twitch drops the connection of anonymous users that send
 }
 
+var (
+	errAnonymous = errors.New("can't send without logging in")
+)
+
 var _ irc.IRC = Gempir{}
+var _ irc.WhisperIRC = Gempir{}
+var _ irc.UserStateIRC = Gempir{}
+var _ irc.ClearChatIRC = Gempir{}
 
 func NewGempirClient(username string, channel string, accessToken string) Gempir {
 	c := twitch.NewClient(username, fmt.Sprintf("oauth:%s", accessToken))
@@ -24,18 +35,121 @@ func NewGempirClient(username string, channel string, accessToken string) Gempir
 	return Gempir{irc: c}
 }
 
+// NewGempirAnonymousClient joins channel as a random justinfan user, which can read chat without logging in
+func NewGempirAnonymousClient(channel string) Gempir {
+	g := newAnonymous(channel)
+	go func() {
+		g.irc.Connect()
+	}()
+
+	return g
+}
+
+func newAnonymous(channel string) Gempir {
+	c := twitch.NewClient(fmt.Sprintf("justinfan%d", 1000+rand.Intn(90000)), "oauth:59301")
+	c.Join(channel)
+	return Gempir{irc: c, anonymous: true}
+}
+
+// NewGempirWhisperClient connects without joining a channel, for receiving whispers
+func NewGempirWhisperClient(username string, accessToken string) Gempir {
+	c := twitch.NewClient(username, fmt.Sprintf("oauth:%s", accessToken))
+	go func() {
+		c.Connect()
+	}()
+
+	return Gempir{irc: c}
+}
+
 func (g Gempir) OnPrivateMessage(f func(types.PrivateMessage)) error {
 	g.irc.OnPrivateMessage(func(message twitch.PrivateMessage) {
-		f(types.PrivateMessage{
-			Name:  message.User.DisplayName,
-			Text:  message.Message,
-			Color: message.User.Color,
+		f(toPrivateMessage(message))
+	})
+	return nil
+}
+
+// ParseLine parses a raw IRC PRIVMSG line, as found in chat logs
+func ParseLine(line string) (types.PrivateMessage, bool) {
+	message, ok := twitch.ParseMessage(line).(*twitch.PrivateMessage)
+	if !ok {
+		return types.PrivateMessage{}, false
+	}
+	pm := toPrivateMessage(*message)
+	pm.Channel = message.Channel
+	return pm, true
+}
+
+func toPrivateMessage(message twitch.PrivateMessage) types.PrivateMessage {
+	pm := types.PrivateMessage{
+		ID:        message.ID,
+		UserID:    message.User.ID,
+		Login:     message.User.Name,
+		Name:      message.User.DisplayName,
+		Text:      message.Message,
+		Color:     message.User.Color,
+		Badges:    message.User.Badges,
+		Time:      message.Time,
+		EmoteOnly: message.Tags["emote-only"] == "1",
+	}
+	if message.Reply != nil {
+		pm.Reply = &types.Reply{
+			ParentID:   message.Reply.ParentMsgID,
+			ParentName: message.Reply.ParentDisplayName,
+			ParentText: message.Reply.ParentMsgBody,
+		}
+	}
+	return pm
+}
+
+func (g Gempir) OnWhisperMessage(f func(types.WhisperMessage)) error {
+	g.irc.OnWhisperMessage(func(message twitch.WhisperMessage) {
+		f(types.WhisperMessage{
+			PrivateMessage: types.PrivateMessage{
+				ID:     message.MessageID,
+				UserID: message.User.ID,
+				Login:  message.User.Name,
+				Name:   message.User.DisplayName,
+				Text:   message.Message,
+				Color:  message.User.Color,
+				Badges: message.User.Badges,
+				Time:   time.Now(),
+			},
+			Conversation: message.User.Name,
+		})
+	})
+	return nil
+}
+
+func (g Gempir) OnUserState(f func(map[string]int)) error {
+	g.irc.OnUserStateMessage(func(message twitch.UserStateMessage) {
+		f(message.User.Badges)
+	})
+	return nil
+}
+
+func (g Gempir) OnClearChat(f func(types.ClearChat)) error {
+	g.irc.OnClearChatMessage(func(message twitch.ClearChatMessage) {
+		f(types.ClearChat{
+			PrivateMessage: types.PrivateMessage{UserID: message.TargetUserID, Time: message.Time},
+			Target:         message.TargetUsername,
+			Duration:       time.Duration(message.BanDuration) * time.Second,
 		})
 	})
 	return nil
 }
 
 func (g Gempir) Publish(channel string, msg string) error {
+	if g.anonymous {
+		return errAnonymous
+	}
 	g.irc.Say(channel, msg)
 	return nil
 }
+
+func (g Gempir) Reply(channel string, parentID string, msg string) error {
+	if g.anonymous {
+		return errAnonymous
+	}
+	g.irc.Reply(channel, parentID, msg)
+	return nil
+}
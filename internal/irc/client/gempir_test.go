@@ -0,0 +1,67 @@
+package client
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnonymous(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	g := newAnonymous("chess")
+	g.irc.IrcAddress = ln.Addr().String()
+	g.irc.TLS = false
+	go g.irc.Connect()
+	defer g.irc.Disconnect()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	lines := bufio.NewScanner(conn)
+
+	// readUntil returns the lines the client sent up to and including the first starting with prefix
+	readUntil := func(prefix string) []string {
+		var read []string
+		for lines.Scan() {
+			read = append(read, lines.Text())
+			if strings.HasPrefix(lines.Text(), prefix) {
+				return read
+			}
+		}
+		t.Fatalf("expected a %s line, got %q: %v", prefix, read, lines.Err())
+		return nil
+	}
+
+	login := readUntil("NICK")
+	if nick := login[len(login)-1]; !regexp.MustCompile(`^NICK justinfan\d+$`).MatchString(nick) {
+		t.Errorf("expected a justinfan nick, got %q", nick)
+	}
+	conn.Write([]byte(":tmi.twitch.tv 001 justinfan :Welcome, GLHF!\r\n"))
+	readUntil("JOIN #chess")
+
+	if err := g.Publish("chess", "hi"); err == nil {
+		t.Errorf("expected an error publishing anonymously")
+	}
+	if err := g.Reply("chess", "1", "hi"); err == nil {
+		t.Errorf("expected an error replying anonymously")
+	}
+
+	// anything sent by Publish or Reply would arrive before the next join
+	g.irc.Join("other")
+	for _, l := range readUntil("JOIN #other") {
+		if strings.Contains(l, "PRIVMSG") {
+			t.Errorf("expected nothing said, got %q", l)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package irc
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Twitch's colors for users who haven't chosen one
+var hashColors = []string{
+	"#FF0000", // Red
+	"#0000FF", // Blue
+	"#008000", // Green
+	"#B22222", // FireBrick
+	"#FF7F50", // Coral
+	"#9ACD32", // YellowGreen
+	"#FF4500", // OrangeRed
+	"#2E8B57", // SeaGreen
+	"#DAA520", // GoldenRod
+	"#D2691E", // Chocolate
+	"#5F9EA0", // CadetBlue
+	"#1E90FF", // DodgerBlue
+	"#FF69B4", // HotPink
+	"#8A2BE2", // BlueViolet
+	"#00FF7F", // SpringGreen
+}
+
+// userColor is the readable color of login's name given their chosen color
+func (s Styles) userColor(login string, color string) string {
+	if color == "" {
+		color = s.NameColor
+		if s.HashNames && login != "" {
+			h := fnv.New32a()
+			h.Write([]byte(strings.ToLower(login)))
+			color = hashColors[h.Sum32()%uint32(len(hashColors))]
+		}
+	}
+	return readable(color, s.Background, s.MinContrast)
+}
+
+// readable moves color toward white on a dark background, or black on a light one,
+// until its contrast ratio with background is at least min
+func readable(color string, background string, min float64) string {
+	c, ok := parseHex(color)
+	bg, bgOK := parseHex(background)
+	if min <= 0 || !ok || !bgOK || contrast(c, bg) >= min {
+		return color
+	}
+
+	target := [3]float64{1, 1, 1}
+	if luminance(bg) > 0.5 {
+		target = [3]float64{0, 0, 0}
+	}
+	for step := 0.05; step <= 1; step += 0.05 {
+		var mixed [3]float64
+		for i := range c {
+			mixed[i] = c[i] + (target[i]-c[i])*step
+		}
+		if contrast(mixed, bg) >= min {
+			return toHex(mixed)
+		}
+	}
+	return toHex(target)
+}
+
+func parseHex(s string) ([3]float64, bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return [3]float64{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return [3]float64{}, false
+	}
+	return [3]float64{float64(v>>16&0xFF) / 255, float64(v>>8&0xFF) / 255, float64(v&0xFF) / 255}, true
+}
+
+func toHex(c [3]float64) string {
+	return fmt.Sprintf("#%02X%02X%02X", int(math.Round(c[0]*255)), int(math.Round(c[1]*255)), int(math.Round(c[2]*255)))
+}
+
+// luminance is the WCAG relative luminance of an sRGB color
+func luminance(c [3]float64) float64 {
+	var l [3]float64
+	for i, v := range c {
+		if v <= 0.03928 {
+			l[i] = v / 12.92
+		} else {
+			l[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*l[0] + 0.7152*l[1] + 0.0722*l[2]
+}
+
+// contrast is the WCAG contrast ratio of two colors, from 1 to 21
+func contrast(a [3]float64, b [3]float64) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
@@ -0,0 +1,188 @@
+package irc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/atye/ttchat/internal/emote"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HighlightRule styles words, pattern matches or every message of users
+type HighlightRule struct {
+	Words      []string `yaml:"words"`      // whole words, ignoring case
+	Regex      string   `yaml:"regex"`      // regular expression, ignoring case
+	Users      []string `yaml:"users"`      // logins whose messages are highlighted
+	Color      string   `yaml:"color"`      // foreground color
+	Background string   `yaml:"background"` // background color
+	Bold       bool     `yaml:"bold"`
+	WholeLine  bool     `yaml:"wholeLine"` // style the whole message instead of the matches
+}
+
+// Highlights are compiled highlight rules
+type Highlights struct {
+	rules []highlight
+}
+
+type highlight struct {
+	words     []*regexp.Regexp
+	regex     *regexp.Regexp
+	users     []string
+	style     lipgloss.Style
+	mention   bool // styled like mentions instead of style
+	wholeLine bool
+}
+
+type span struct {
+	start int
+	end   int
+	style lipgloss.Style
+}
+
+// NewHighlights compiles rules, applied in order after the mention of the user's own name
+func NewHighlights(rules []HighlightRule) (Highlights, error) {
+	var h Highlights
+	for i, r := range rules {
+		c := highlight{users: r.Users, wholeLine: r.WholeLine}
+		c.style, c.mention = ruleStyle(r)
+		for _, w := range r.Words {
+			if w == "" {
+				continue
+			}
+			c.words = append(c.words, regexp.MustCompile(fmt.Sprintf("(?i)%s", regexp.QuoteMeta(w))))
+		}
+		if r.Regex != "" {
+			re, err := regexp.Compile(fmt.Sprintf("(?i)%s", r.Regex))
+			if err != nil {
+				return Highlights{}, fmt.Errorf("highlight #%d: %v", i+1, err)
+			}
+			c.regex = re
+		}
+		h.rules = append(h.rules, c)
+	}
+	return h, nil
+}
+
+// ruleStyle is the style of r, or reports that r has none and is styled like mentions
+func ruleStyle(r HighlightRule) (lipgloss.Style, bool) {
+	if r.Color == "" && r.Background == "" && !r.Bold {
+		return lipgloss.NewStyle(), true
+	}
+	s := lipgloss.NewStyle().Bold(r.Bold)
+	if r.Color != "" {
+		s = s.Foreground(lipgloss.Color(r.Color))
+	}
+	if r.Background != "" {
+		s = s.Background(lipgloss.Color(r.Background))
+	}
+	return s, false
+}
+
+// mentionHighlight highlights @displayName
+func mentionHighlight(displayName string) highlight {
+	h := highlight{mention: true}
+	if displayName != "" {
+		h.words = []*regexp.Regexp{regexp.MustCompile(fmt.Sprintf("(?i)@%s", regexp.QuoteMeta(displayName)))}
+	}
+	return h
+}
+
+// styleText styles the highlights and emotes of a message from login.
+// It reports whether any highlight matched.
+func styleText(text string, login string, rules []highlight, emotes *emote.Set, styles Styles) (string, bool) {
+	var spans []span
+	for _, r := range rules {
+		style := r.style
+		if r.mention {
+			style = styles.Mention
+		}
+		for _, u := range r.users {
+			if login != "" && strings.EqualFold(u, login) {
+				return style.Render(text), true
+			}
+		}
+
+		found := r.find(text)
+		if len(found) == 0 {
+			continue
+		}
+		if r.wholeLine {
+			return style.Render(text), true
+		}
+		for _, f := range found {
+			if !overlaps(spans, f) {
+				spans = append(spans, span{start: f[0], end: f[1], style: style})
+			}
+		}
+	}
+
+	if len(spans) == 0 {
+		return styleEmotes(text, emotes, styles.Emote), false
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(styleEmotes(text[last:s.start], emotes, styles.Emote))
+		b.WriteString(s.style.Render(text[s.start:s.end]))
+		last = s.end
+	}
+	b.WriteString(styleEmotes(text[last:], emotes, styles.Emote))
+	return b.String(), true
+}
+
+// find returns the byte ranges of whole words and pattern matches of h in text
+func (h highlight) find(text string) [][]int {
+	var found [][]int
+	for _, w := range h.words {
+		for _, loc := range w.FindAllStringIndex(text, -1) {
+			if isWordBoundary(text, loc[0], loc[1]) {
+				found = append(found, loc)
+			}
+		}
+	}
+	if h.regex != nil {
+		for _, loc := range h.regex.FindAllStringIndex(text, -1) {
+			if loc[0] != loc[1] {
+				found = append(found, loc)
+			}
+		}
+	}
+	return found
+}
+
+// isWordBoundary reports whether text[start:end] isn't part of a longer word
+func isWordBoundary(text string, start int, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		first, _ := utf8.DecodeRuneInString(text[start:])
+		if isWordRune(first) {
+			return false
+		}
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		last, _ := utf8.DecodeLastRuneInString(text[:end])
+		if isWordRune(last) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func overlaps(spans []span, loc []int) bool {
+	for _, s := range spans {
+		if loc[0] < s.end && s.start < loc[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atye/ttchat/internal/duration"
+	"github.com/atye/ttchat/internal/terminal"
+	"github.com/atye/ttchat/internal/types"
+)
+
+// ModerationAPI takes moderator actions against users in a channel
+type ModerationAPI interface {
+	BanUser(string, string, time.Duration, string) error // channel, user login, timeout duration (0 bans), reason
+	UnbanUser(string, string) error                      // channel, user login
+	DeleteMessage(string, string) error                  // channel, message ID
+}
+
+// UserStateIRC is an IRC connection that reports the user's own badges in the joined channel
+type UserStateIRC interface {
+	OnUserState(func(map[string]int)) error
+}
+
+// ClearChatIRC is an IRC connection that reports timeouts and bans in the joined channel
+type ClearChatIRC interface {
+	OnClearChat(func(types.ClearChat)) error
+}
+
+var _ terminal.Moderator = Twitch{}
+
+// WithModeration enables moderator actions through api
+func WithModeration(api ModerationAPI) Option {
+	return func(t *Twitch) {
+		t.moderation = api
+	}
+}
+
+func (c Twitch) ModerationEnabled() bool {
+	return c.moderation != nil
+}
+
+func (c Twitch) IsModerator() bool {
+	return c.moderation != nil && c.moderator.Load()
+}
+
+func (c Twitch) Timeout(login string, d time.Duration, reason string) {
+	if d < time.Second {
+		d = time.Second
+	}
+	c.moderate(fmt.Sprintf("timed out @%s for %s", login, duration.Format(d)), func() error {
+		return c.moderation.BanUser(c.channel, login, d, reason)
+	})
+}
+
+func (c Twitch) Ban(login string, reason string) {
+	c.moderate(fmt.Sprintf("banned @%s", login), func() error {
+		return c.moderation.BanUser(c.channel, login, 0, reason)
+	})
+}
+
+func (c Twitch) Unban(login string) {
+	c.moderate(fmt.Sprintf("unbanned @%s", login), func() error {
+		return c.moderation.UnbanUser(c.channel, login)
+	})
+}
+
+func (c Twitch) Delete(messageID string) {
+	c.moderate("deleted message", func() error {
+		return c.moderation.DeleteMessage(c.channel, messageID)
+	})
+}
+
+// moderate runs action in the background and reports its result to the channel
+func (c Twitch) moderate(done string, action func() error) {
+	if !c.IsModerator() {
+		go func() {
+			c.upstream <- c.styles.systemMessage(c.channel, "you are not a moderator of this channel")
+		}()
+		return
+	}
+
+	go func() {
+		err := action()
+		if err != nil {
+			c.log.Printf("irc: moderating %s: %v\n", c.channel, err)
+			c.upstream <- c.styles.systemMessage(c.channel, fmt.Sprintf("moderation failed: %v", err))
+			return
+		}
+		c.upstream <- c.styles.systemMessage(c.channel, done)
+	}()
+}
+
+func (c Twitch) watchUserState() {
+	if strings.EqualFold(c.displayName, c.channel) {
+		c.moderator.Store(true)
+	}
+
+	us, ok := c.irc.(UserStateIRC)
+	if !ok {
+		return
+	}
+
+	err := us.OnUserState(func(badges map[string]int) {
+		_, mod := badges["moderator"]
+		_, broadcaster := badges["broadcaster"]
+		c.moderator.Store(mod || broadcaster)
+	})
+	if err != nil {
+		c.log.Printf("irc: setting OnUserState behavior: %v\n", err)
+	}
+}
+
+// watchClearChat shows timeouts and bans as notices in the channel
+func (c Twitch) watchClearChat() {
+	cc, ok := c.irc.(ClearChatIRC)
+	if !ok {
+		return
+	}
+
+	err := cc.OnClearChat(func(incoming types.ClearChat) {
+		text := "chat was cleared"
+		switch {
+		case incoming.Target != "" && incoming.Duration > 0:
+			text = fmt.Sprintf("%s was timed out for %s", incoming.Target, duration.Format(incoming.Duration))
+		case incoming.Target != "":
+			text = fmt.Sprintf("%s was banned", incoming.Target)
+		}
+
+		notice := c.styles.systemMessage(c.channel, text)
+		notice.Login = incoming.Login
+		notice.UserID = incoming.UserID
+		if !incoming.Time.IsZero() {
+			notice.Time = incoming.Time
+		}
+		incoming.PrivateMessage = notice
+		c.upstream <- incoming
+	})
+	if err != nil {
+		c.log.Printf("irc: setting OnClearChat behavior: %v\n", err)
+	}
+}
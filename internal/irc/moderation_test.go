@@ -0,0 +1,195 @@
+package irc
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/atye/ttchat/internal/types"
+)
+
+type mockUserStateIrc struct {
+	mockIrc
+	userState func(map[string]int)
+}
+
+func (i *mockUserStateIrc) OnUserState(f func(map[string]int)) error {
+	i.userState = f
+	return nil
+}
+
+type mockModerationAPI struct {
+	calls []string
+	err   error
+}
+
+func (a *mockModerationAPI) BanUser(channel string, login string, d time.Duration, reason string) error {
+	a.calls = append(a.calls, fmt.Sprintf("ban %s %s %s %s", channel, login, d, reason))
+	return a.err
+}
+
+func (a *mockModerationAPI) UnbanUser(channel string, login string) error {
+	a.calls = append(a.calls, fmt.Sprintf("unban %s %s", channel, login))
+	return a.err
+}
+
+func (a *mockModerationAPI) DeleteMessage(channel string, id string) error {
+	a.calls = append(a.calls, fmt.Sprintf("delete %s %s", channel, id))
+	return a.err
+}
+
+func TestModeration(t *testing.T) {
+	tests := []struct {
+		Name     string
+		badges   map[string]int
+		apiErr   error
+		action   func(Twitch)
+		wantCall string
+		wantText string
+	}{
+		{
+			"timeout",
+			map[string]int{"moderator": 1},
+			nil,
+			func(tw Twitch) { tw.Timeout("foo", 10*time.Minute, "spam") },
+			"ban testChannel foo 10m0s spam",
+			DefaultStyles().System.Render("timed out @foo for 10m"),
+		},
+		{
+			"ban as broadcaster",
+			map[string]int{"broadcaster": 1},
+			nil,
+			func(tw Twitch) { tw.Ban("foo", "") },
+			"ban testChannel foo 0s ",
+			DefaultStyles().System.Render("banned @foo"),
+		},
+		{
+			"unban",
+			map[string]int{"moderator": 1},
+			nil,
+			func(tw Twitch) { tw.Unban("foo") },
+			"unban testChannel foo",
+			DefaultStyles().System.Render("unbanned @foo"),
+		},
+		{
+			"delete",
+			map[string]int{"moderator": 1},
+			nil,
+			func(tw Twitch) { tw.Delete("abc") },
+			"delete testChannel abc",
+			DefaultStyles().System.Render("deleted message"),
+		},
+		{
+			"api error",
+			map[string]int{"moderator": 1},
+			fmt.Errorf("missing scope"),
+			func(tw Twitch) { tw.Unban("foo") },
+			"unban testChannel foo",
+			DefaultStyles().System.Render("moderation failed: missing scope"),
+		},
+		{
+			"not a moderator",
+			map[string]int{"subscriber": 12},
+			nil,
+			func(tw Twitch) { tw.Ban("foo", "") },
+			"",
+			DefaultStyles().System.Render("you are not a moderator of this channel"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			incomingIRC := &mockUserStateIrc{}
+			api := &mockModerationAPI{err: test.apiErr}
+			tw := NewTwitch(incomingIRC, log.New(io.Discard, "", 0), "user", "testChannel", WithModeration(api))
+
+			incomingIRC.userState(test.badges)
+			s := tw.IncomingMessages()
+			test.action(tw)
+
+			m := <-s
+			if m.GetText() != test.wantText {
+				t.Errorf("expected text %s, got %s", test.wantText, m.GetText())
+			}
+
+			var gotCall string
+			if len(api.calls) > 0 {
+				gotCall = api.calls[0]
+			}
+			if gotCall != test.wantCall {
+				t.Errorf("expected call %q, got %q", test.wantCall, gotCall)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		tw := NewTwitch(&mockIrc{}, log.New(io.Discard, "", 0), "user", "user")
+		if tw.ModerationEnabled() || tw.IsModerator() {
+			t.Error("expected no moderator actions without WithModeration")
+		}
+	})
+
+	t.Run("own channel", func(t *testing.T) {
+		tw := NewTwitch(&mockIrc{}, log.New(io.Discard, "", 0), "User", "user", WithModeration(&mockModerationAPI{}))
+		if !tw.ModerationEnabled() || !tw.IsModerator() {
+			t.Error("expected moderator in own channel")
+		}
+	})
+}
+
+type mockClearChatIrc struct {
+	mockIrc
+	clearChat func(types.ClearChat)
+}
+
+func (i *mockClearChatIrc) OnClearChat(f func(types.ClearChat)) error {
+	i.clearChat = f
+	return nil
+}
+
+func TestClearChat(t *testing.T) {
+	tests := []struct {
+		Name     string
+		incoming types.ClearChat
+		wantText string
+	}{
+		{
+			"timeout",
+			types.ClearChat{Target: "foo", Duration: 10 * time.Minute},
+			DefaultStyles().System.Render("foo was timed out for 10m"),
+		},
+		{
+			"ban",
+			types.ClearChat{Target: "foo"},
+			DefaultStyles().System.Render("foo was banned"),
+		},
+		{
+			"clear",
+			types.ClearChat{},
+			DefaultStyles().System.Render("chat was cleared"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			incomingIRC := &mockClearChatIrc{}
+			tw := NewTwitch(incomingIRC, log.New(io.Discard, "", 0), "user", "testChannel")
+
+			s := tw.IncomingMessages()
+			go incomingIRC.clearChat(test.incoming)
+
+			m, ok := (<-s).(types.ClearChat)
+			if !ok {
+				t.Fatal("expected a ClearChat message")
+			}
+			if m.GetText() != test.wantText {
+				t.Errorf("expected text %s, got %s", test.wantText, m.GetText())
+			}
+			if m.GetChannel() != "testChannel" || m.Target != test.incoming.Target {
+				t.Errorf("expected target %s in testChannel, got %s in %s", test.incoming.Target, m.Target, m.GetChannel())
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package irc
+
+import (
+	"github.com/atye/ttchat/internal/terminal"
+	"github.com/atye/ttchat/internal/types"
+)
+
+// Tab is a read-only tab showing messages routed to it from other channels
+type Tab struct {
+	name     string
+	upstream chan types.Message
+	styles   Styles
+}
+
+var _ terminal.IRC = Tab{}
+
+func NewTab(name string, styles Styles) Tab {
+	return Tab{
+		name:     name,
+		upstream: make(chan types.Message),
+		styles:   styles,
+	}
+}
+
+func (t Tab) IncomingMessages() <-chan types.Message {
+	return t.upstream
+}
+
+func (t Tab) Publish(string) {
+	go func() {
+		t.upstream <- t.styles.systemMessage(t.name, "this tab is read-only")
+	}()
+}
+
+func (t Tab) Reply(types.Message, string) {
+	t.Publish("")
+}
@@ -1,19 +1,25 @@
 package irc
 
 import (
-	"fmt"
 	"log"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/atye/ttchat/internal/emote"
+	"github.com/atye/ttchat/internal/filter"
 	"github.com/atye/ttchat/internal/terminal"
+	"github.com/atye/ttchat/internal/theme"
 	"github.com/atye/ttchat/internal/types"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Generic interface for doing something with an IRC connection
 type IRC interface {
 	OnPrivateMessage(func(types.PrivateMessage)) error
-	Publish(string, string) error // channel, message
+	Publish(string, string) error       // channel, message
+	Reply(string, string, string) error // channel, parent message ID, message
 }
 
 type Twitch struct {
@@ -22,40 +28,161 @@ type Twitch struct {
 	irc         IRC
 	upstream    chan types.Message
 	log         *log.Logger
+	emotes      *emote.Set
+	moderation  ModerationAPI
+	moderator   *atomic.Bool
+	ignorer     Ignorer
+	collapse    bool
+	filters     *filter.Engine
+	highlights  []highlight
+	styles      Styles
+}
+
+// Ignorer decides whether messages from a user are hidden in a channel
+type Ignorer interface {
+	Ignored(string, string, string) bool // channel, user login, user ID
+}
+
+type Option func(*Twitch)
+
+// WithFilters applies the action of the first filter rule matching each incoming message
+func WithFilters(filters *filter.Engine) Option {
+	return func(t *Twitch) {
+		t.filters = filters
+	}
+}
+
+// WithHighlights styles messages matching highlights, after mentions of the user's own name
+func WithHighlights(highlights Highlights) Option {
+	return func(t *Twitch) {
+		t.highlights = append(t.highlights, highlights.rules...)
+	}
+}
+
+// WithIgnore drops messages from ignored users, or shows them collapsed when collapse is set
+func WithIgnore(ignorer Ignorer, collapse bool) Option {
+	return func(t *Twitch) {
+		t.ignorer = ignorer
+		t.collapse = collapse
+	}
+}
+
+// WithStyles styles messages with styles instead of DefaultStyles
+func WithStyles(styles Styles) Option {
+	return func(t *Twitch) {
+		t.styles = styles
+	}
+}
+
+// WithEmotes styles third-party emote codes found in messages
+func WithEmotes(emotes *emote.Set) Option {
+	return func(t *Twitch) {
+		t.emotes = emotes
+	}
 }
 
 const (
 	DefaultNameColor   = "#1E90FF" //Dodger Blue
 	UserHighlightColor = "#6441A5" //Twitch purple
+	EmoteColor         = "#FFB31A" //Amber
+	SystemColor        = "#808080" //Gray
+	FilterColor        = "#3A3A3A" //Charcoal
 )
 
 var (
-	UserHighLightStyle = lipgloss.NewStyle().Bold(true).Background(lipgloss.Color(UserHighlightColor))
+	DimStyle = lipgloss.NewStyle().Faint(true)
 )
 
+// Styles are how messages are styled
+type Styles struct {
+	NameColor string         // names of users without a color
+	Mention   lipgloss.Style // the user's own name, mentions of it and highlight rules without a style
+	Emote     lipgloss.Style
+	System    lipgloss.Style // notices from ttchat
+
+	HashNames   bool    // pick a color from the login of users without one instead of NameColor
+	Background  string  // the color names are made readable against
+	MinContrast float64 // the contrast ratio names need against Background, none when 0
+}
+
+// DefaultStyles are the styles of messages without a theme
+func DefaultStyles() Styles {
+	return Styles{
+		NameColor: DefaultNameColor,
+		Mention:   lipgloss.NewStyle().Bold(true).Background(lipgloss.Color(UserHighlightColor)),
+		Emote:     lipgloss.NewStyle().Italic(true).Foreground(lipgloss.Color(EmoteColor)),
+		System:    lipgloss.NewStyle().Foreground(lipgloss.Color(SystemColor)),
+	}
+}
+
+// NewStyles returns the styles of messages in t
+func NewStyles(t theme.Theme) Styles {
+	return Styles{
+		NameColor: t.NameColor,
+		Mention:   t.Mention.Lipgloss(),
+		Emote:     t.Emote.Lipgloss(),
+		System:    t.System.Lipgloss(),
+
+		Background: t.Background,
+	}
+}
+
 var _ terminal.IRC = Twitch{}
 
-func NewTwitch(irc IRC, log *log.Logger, displayName string, channel string) Twitch {
+func NewTwitch(irc IRC, log *log.Logger, displayName string, channel string, opts ...Option) Twitch {
 	s := Twitch{
 		irc:         irc,
 		displayName: displayName,
 		channel:     channel,
 		upstream:    make(chan types.Message),
 		log:         log,
+		moderator:   &atomic.Bool{},
+		highlights:  []highlight{mentionHighlight(displayName)},
+		styles:      DefaultStyles(),
+	}
+	for _, opt := range opts {
+		opt(&s)
 	}
 
+	if s.moderation != nil {
+		s.watchUserState()
+	}
+	s.watchClearChat()
+
 	err := s.irc.OnPrivateMessage(func(incoming types.PrivateMessage) {
+		if s.ignorer != nil && s.ignorer.Ignored(channel, incoming.Login, incoming.UserID) {
+			if s.collapse {
+				collapsed := incoming
+				collapsed.Channel = channel
+				collapsed.Name = s.styles.System.Render(incoming.Name)
+				collapsed.Text = s.styles.System.Render("[ignored]")
+				collapsed.Reply = nil
+				s.upstream <- collapsed
+			}
+			return
+		}
+
+		if !incoming.EmoteOnly {
+			incoming.EmoteOnly = emoteOnly(incoming.Text, s.emotes)
+		}
+		rule, filtered := s.filters.Match(channel, incoming)
+		if filtered && rule.Action == filter.Hide {
+			return
+		}
+
 		styled := incoming
 		styled.Channel = channel
-		if styled.Color == "" {
-			styled.Color = DefaultNameColor
-		}
+		styled.Color = s.styles.userColor(styled.Login, styled.Color)
 
-		styled.Text = highlightUserMentions(styled.Text, s.displayName)
+		styled.Text, styled.Highlighted = styleText(styled.Text, styled.Login, s.highlights, s.emotes, s.styles)
 
 		styled.Name = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(styled.Color)).Render(styled.Name)
 		if incoming.Name == s.displayName {
-			styled.Name = UserHighLightStyle.Render(s.displayName)
+			styled.Name = s.styles.Mention.Render(s.displayName)
+		}
+
+		if filtered {
+			styled = applyFilter(rule, incoming, styled)
 		}
 
 		s.upstream <- styled
@@ -75,19 +202,82 @@ func (c Twitch) IncomingMessages() <-chan types.Message {
 
 func (c Twitch) Publish(msg string) {
 	c.irc.Publish(c.channel, msg)
-	c.upstream <- types.PrivateMessage{
-		Name:    UserHighLightStyle.Render(c.displayName),
-		Text:    highlightUserMentions(msg, c.displayName),
+	c.upstream <- c.ownMessage(msg)
+}
+
+func (c Twitch) Reply(parent types.Message, msg string) {
+	c.irc.Reply(c.channel, parent.GetID(), msg)
+	own := c.ownMessage(msg)
+	own.Reply = &types.Reply{
+		ParentID:   parent.GetID(),
+		ParentName: ansi.Strip(parent.GetName()),
+		ParentText: ansi.Strip(parent.GetText()),
+	}
+	c.upstream <- own
+}
+
+func (c Twitch) ownMessage(msg string) types.PrivateMessage {
+	text, _ := styleText(msg, "", c.highlights, c.emotes, c.styles)
+	return types.PrivateMessage{
+		Name:    c.styles.Mention.Render(c.displayName),
+		Text:    text,
 		Channel: c.channel,
+		Time:    time.Now(),
 	}
 }
 
-func highlightUserMentions(text string, displayName string) string {
+// applyFilter changes how a styled message is shown according to the action of rule
+func applyFilter(rule filter.Rule, incoming types.PrivateMessage, styled types.PrivateMessage) types.PrivateMessage {
+	switch rule.Action {
+	case filter.Dim:
+		styled.Name = DimStyle.Render(incoming.Name)
+		styled.Text = DimStyle.Render(incoming.Text)
+	case filter.Highlight:
+		color := rule.Color
+		if color == "" {
+			color = FilterColor
+		}
+		styled.Text = lipgloss.NewStyle().Background(lipgloss.Color(color)).Render(incoming.Text)
+	case filter.Route:
+		styled.Source = styled.Channel
+		styled.Channel = rule.Tab
+	}
+	return styled
+}
+
+func emoteOnly(text string, emotes *emote.Set) bool {
+	if emotes.Len() == 0 {
+		return false
+	}
+	words := strings.Fields(text)
+	for _, w := range words {
+		if _, ok := emotes.Lookup(w); !ok {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+func styleEmotes(text string, emotes *emote.Set, style lipgloss.Style) string {
+	if emotes.Len() == 0 {
+		return text
+	}
+
 	texts := strings.Split(text, " ")
 	for i, w := range texts {
-		if strings.Contains(strings.ToLower(w), fmt.Sprintf("@%s", strings.ToLower(displayName))) {
-			texts[i] = UserHighLightStyle.Render(w)
+		if _, ok := emotes.Lookup(w); ok {
+			texts[i] = style.Render(w)
 		}
 	}
 	return strings.Join(texts, " ")
 }
+
+// systemMessage is a notice from ttchat itself rather than from chat
+func (s Styles) systemMessage(channel string, text string) types.PrivateMessage {
+	return types.PrivateMessage{
+		Channel: channel,
+		Name:    s.System.Render("ttchat"),
+		Text:    s.System.Render(text),
+		Time:    time.Now(),
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"log"
 	"testing"
+	"time"
 
+	"github.com/atye/ttchat/internal/emote"
+	"github.com/atye/ttchat/internal/filter"
 	"github.com/atye/ttchat/internal/types"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type mockIrc struct {
 	callback func(types.PrivateMessage)
+	parentID string
 }
 
 func (i *mockIrc) OnPrivateMessage(f func(types.PrivateMessage)) error {
@@ -20,6 +24,11 @@ func (i *mockIrc) OnPrivateMessage(f func(types.PrivateMessage)) error {
 
 func (i *mockIrc) Publish(string, string) error { return nil }
 
+func (i *mockIrc) Reply(channel string, parentID string, msg string) error {
+	i.parentID = parentID
+	return nil
+}
+
 func TestIncomingMessages(t *testing.T) {
 	tests := []struct {
 		Name            string
@@ -57,7 +66,7 @@ func TestIncomingMessages(t *testing.T) {
 				Text: "hi @user",
 			},
 			lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(DefaultNameColor)).Render("foo"),
-			fmt.Sprintf("hi %s", UserHighLightStyle.Render("@user")),
+			fmt.Sprintf("hi %s", DefaultStyles().Mention.Render("@user")),
 		},
 		{
 			"incoming mention mix case",
@@ -67,7 +76,7 @@ func TestIncomingMessages(t *testing.T) {
 				Text: "hi @user",
 			},
 			lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(DefaultNameColor)).Render("foo"),
-			fmt.Sprintf("hi %s", UserHighLightStyle.Render("@user")),
+			fmt.Sprintf("hi %s", DefaultStyles().Mention.Render("@user")),
 		},
 		{
 			"incoming is you",
@@ -114,7 +123,7 @@ func TestPublish(t *testing.T) {
 			"publish message",
 			"user",
 			"testText",
-			UserHighLightStyle.Render("user"),
+			DefaultStyles().Mention.Render("user"),
 			"testText",
 		},
 	}
@@ -139,3 +148,351 @@ func TestPublish(t *testing.T) {
 		})
 	}
 }
+
+func TestIncomingEmotes(t *testing.T) {
+	emotes := emote.NewSet()
+	emotes.Add(emote.Emote{ID: "1", Code: "catJAM", Provider: emote.BTTV})
+
+	incomingIRC := &mockIrc{}
+	i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithEmotes(emotes))
+
+	s := i.IncomingMessages()
+	go incomingIRC.callback(types.PrivateMessage{Name: "foo", Text: "catJAM @user catjam"})
+
+	m := <-s
+
+	want := fmt.Sprintf("%s %s catjam", DefaultStyles().Emote.Render("catJAM"), DefaultStyles().Mention.Render("@user"))
+	if m.GetText() != want {
+		t.Errorf("expected text %s, got %s", want, m.GetText())
+	}
+}
+
+func TestReply(t *testing.T) {
+	incomingIRC := &mockIrc{}
+	i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel")
+
+	parent := types.PrivateMessage{
+		ID:   "abc",
+		Name: lipgloss.NewStyle().Bold(true).Render("foo"),
+		Text: "bar",
+	}
+
+	s := i.IncomingMessages()
+	go i.Reply(parent, "testText")
+
+	m := <-s
+
+	if incomingIRC.parentID != "abc" {
+		t.Errorf("expected parent ID abc, got %s", incomingIRC.parentID)
+	}
+
+	want := types.Reply{ParentID: "abc", ParentName: "foo", ParentText: "bar"}
+	if m.GetReply() == nil || *m.GetReply() != want {
+		t.Errorf("expected reply %v, got %v", want, m.GetReply())
+	}
+
+	if m.GetText() != "testText" {
+		t.Errorf("expected text testText, got %s", m.GetText())
+	}
+}
+
+type mockIgnorer struct {
+	login string
+}
+
+func (i mockIgnorer) Ignored(channel string, login string, userID string) bool {
+	return login == i.login
+}
+
+func TestIgnore(t *testing.T) {
+	t.Run("hidden", func(t *testing.T) {
+		incomingIRC := &mockIrc{}
+		i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithIgnore(mockIgnorer{login: "spambot"}, false))
+
+		s := i.IncomingMessages()
+		go func() {
+			incomingIRC.callback(types.PrivateMessage{Login: "spambot", Name: "SpamBot", Text: "buy followers"})
+			incomingIRC.callback(types.PrivateMessage{Login: "foo", Name: "foo", Text: "bar"})
+		}()
+
+		m := <-s
+		if m.GetText() != "bar" {
+			t.Errorf("expected ignored message to be dropped, got %s", m.GetText())
+		}
+	})
+
+	t.Run("collapsed", func(t *testing.T) {
+		incomingIRC := &mockIrc{}
+		i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithIgnore(mockIgnorer{login: "spambot"}, true))
+
+		s := i.IncomingMessages()
+		go incomingIRC.callback(types.PrivateMessage{Login: "spambot", Name: "SpamBot", Text: "buy followers"})
+
+		m := <-s
+		if want := DefaultStyles().System.Render("[ignored]"); m.GetText() != want {
+			t.Errorf("expected text %s, got %s", want, m.GetText())
+		}
+		if want := DefaultStyles().System.Render("SpamBot"); m.GetName() != want {
+			t.Errorf("expected name %s, got %s", want, m.GetName())
+		}
+	})
+}
+
+func TestFilters(t *testing.T) {
+	filters, err := filter.New([]filter.Rule{
+		{Name: "commands", Text: `^!`, Action: filter.Hide},
+		{Name: "bots", User: `bot$`, Action: filter.Route, Tab: "Bots"},
+		{Name: "quiet", User: `^quiet$`, Action: filter.Dim},
+		{Name: "mods", Badges: []string{"moderator"}, Action: filter.Highlight, Color: "#FF0000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name        string
+		pm          types.PrivateMessage
+		wantChannel string
+		wantSource  string
+		wantText    string
+	}{
+		{
+			"route",
+			types.PrivateMessage{Login: "nightbot", Name: "Nightbot", Text: "hi"},
+			"Bots",
+			"testChannel",
+			"hi",
+		},
+		{
+			"dim",
+			types.PrivateMessage{Login: "quiet", Name: "quiet", Text: "hi"},
+			"testChannel",
+			"",
+			DimStyle.Render("hi"),
+		},
+		{
+			"highlight",
+			types.PrivateMessage{Login: "mod", Name: "mod", Text: "hi", Badges: map[string]int{"moderator": 1}},
+			"testChannel",
+			"",
+			lipgloss.NewStyle().Background(lipgloss.Color("#FF0000")).Render("hi"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			incomingIRC := &mockIrc{}
+			i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithFilters(filters))
+
+			s := i.IncomingMessages()
+			go func() {
+				incomingIRC.callback(types.PrivateMessage{Login: "foo", Name: "foo", Text: "!hidden"})
+				incomingIRC.callback(test.pm)
+			}()
+
+			m := <-s
+			if m.GetChannel() != test.wantChannel {
+				t.Errorf("expected channel %s, got %s", test.wantChannel, m.GetChannel())
+			}
+			if m.GetSource() != test.wantSource {
+				t.Errorf("expected source %s, got %s", test.wantSource, m.GetSource())
+			}
+			if m.GetText() != test.wantText {
+				t.Errorf("expected text %s, got %s", test.wantText, m.GetText())
+			}
+		})
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	green := lipgloss.NewStyle().Background(lipgloss.Color("#00FF00"))
+	bold := lipgloss.NewStyle().Bold(true)
+
+	highlights, err := NewHighlights([]HighlightRule{
+		{Words: []string{"giveaway"}, Background: "#00FF00"},
+		{Regex: `po+g`, Bold: true},
+		{Users: []string{"friend"}, Background: "#00FF00"},
+		{Words: []string{"urgent"}, Background: "#00FF00", WholeLine: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name            string
+		pm              types.PrivateMessage
+		wantText        string
+		wantHighlighted bool
+	}{
+		{
+			"no match",
+			types.PrivateMessage{Login: "foo", Text: "hello there"},
+			"hello there",
+			false,
+		},
+		{
+			"mention on word boundary",
+			types.PrivateMessage{Login: "foo", Text: "hi @User, and @user2"},
+			fmt.Sprintf("hi %s, and @user2", DefaultStyles().Mention.Render("@User")),
+			true,
+		},
+		{
+			"longer name isn't a mention",
+			types.PrivateMessage{Login: "foo", Text: "hi @username2"},
+			"hi @username2",
+			false,
+		},
+		{
+			"word ignoring case",
+			types.PrivateMessage{Login: "foo", Text: "GIVEAWAY now, no giveaways"},
+			fmt.Sprintf("%s now, no giveaways", green.Render("GIVEAWAY")),
+			true,
+		},
+		{
+			"regex",
+			types.PrivateMessage{Login: "foo", Text: "that was POOOG"},
+			fmt.Sprintf("that was %s", bold.Render("POOOG")),
+			true,
+		},
+		{
+			"user",
+			types.PrivateMessage{Login: "Friend", Text: "hey"},
+			green.Render("hey"),
+			true,
+		},
+		{
+			"whole line",
+			types.PrivateMessage{Login: "foo", Text: "this is urgent"},
+			green.Render("this is urgent"),
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			incomingIRC := &mockIrc{}
+			i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithHighlights(highlights))
+
+			s := i.IncomingMessages()
+			go incomingIRC.callback(test.pm)
+
+			m := <-s
+			if m.GetText() != test.wantText {
+				t.Errorf("expected text %s, got %s", test.wantText, m.GetText())
+			}
+			if m.IsHighlighted() != test.wantHighlighted {
+				t.Errorf("expected highlighted %t, got %t", test.wantHighlighted, m.IsHighlighted())
+			}
+		})
+	}
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := NewHighlights([]HighlightRule{{Regex: "("}})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestStyles(t *testing.T) {
+	styles := Styles{
+		NameColor: "#111111",
+		Mention:   lipgloss.NewStyle().Underline(true),
+		System:    lipgloss.NewStyle().Italic(true),
+	}
+	highlights, err := NewHighlights([]HighlightRule{{Words: []string{"giveaway"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	incomingIRC := &mockIrc{}
+	i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithHighlights(highlights), WithStyles(styles))
+	s := i.IncomingMessages()
+
+	t.Run("mentions and rules without a style", func(t *testing.T) {
+		go incomingIRC.callback(types.PrivateMessage{Login: "foo", Name: "foo", Text: "@user giveaway"})
+
+		m := <-s
+		want := fmt.Sprintf("%s %s", styles.Mention.Render("@user"), styles.Mention.Render("giveaway"))
+		if m.GetText() != want {
+			t.Errorf("expected text %s, got %s", want, m.GetText())
+		}
+		if m.GetColor() != styles.NameColor {
+			t.Errorf("expected color %s, got %s", styles.NameColor, m.GetColor())
+		}
+	})
+
+	t.Run("own name", func(t *testing.T) {
+		go i.Publish("hi")
+
+		m := <-s
+		if want := styles.Mention.Render("user"); m.GetName() != want {
+			t.Errorf("expected name %s, got %s", want, m.GetName())
+		}
+	})
+
+	t.Run("notices", func(t *testing.T) {
+		i.Timeout("foo", time.Minute, "")
+
+		m := <-s
+		if want := styles.System.Render("ttchat"); m.GetName() != want {
+			t.Errorf("expected name %s, got %s", want, m.GetName())
+		}
+	})
+}
+
+func TestNameColors(t *testing.T) {
+	dark := DefaultStyles()
+	dark.HashNames = true
+	dark.Background = "#000000"
+	dark.MinContrast = 4.5
+
+	light := dark
+	light.Background = "#FFFFFF"
+
+	tests := []struct {
+		Name      string
+		styles    Styles
+		pm        types.PrivateMessage
+		wantColor string
+	}{
+		{"readable color", dark, types.PrivateMessage{Login: "foo", Name: "foo", Color: "#FF69B4"}, "#FF69B4"},
+		{"dark color lightened", dark, types.PrivateMessage{Login: "foo", Name: "foo", Color: "#0000FF"}, "#6666FF"},
+		{"hash color", dark, types.PrivateMessage{Login: "Foo", Name: "Foo"}, "#C55959"},
+		{"light color darkened", light, types.PrivateMessage{Login: "foo", Name: "foo", Color: "#FFFF00"}, "#737300"},
+		{"no background", DefaultStyles(), types.PrivateMessage{Login: "foo", Name: "foo", Color: "#0000FF"}, "#0000FF"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			incomingIRC := &mockIrc{}
+			i := NewTwitch(incomingIRC, log.Default(), "user", "testChannel", WithStyles(test.styles))
+
+			s := i.IncomingMessages()
+			go incomingIRC.callback(test.pm)
+
+			m := <-s
+			if m.GetColor() != test.wantColor {
+				t.Errorf("expected color %s, got %s", test.wantColor, m.GetColor())
+			}
+		})
+	}
+
+	t.Run("hash color is stable and readable", func(t *testing.T) {
+		c := dark.userColor("foo", "")
+		if c != dark.userColor("FOO", "") {
+			t.Errorf("expected the same color for foo and FOO")
+		}
+		fg, _ := parseHex(c)
+		bg, _ := parseHex("#000000")
+		if got := contrast(fg, bg); got < 4.5 {
+			t.Errorf("expected contrast of at least 4.5, got %f", got)
+		}
+	})
+
+	t.Run("light background", func(t *testing.T) {
+		if got := readable("#FFFF00", "#FFFFFF", 4.5); got == "#FFFF00" {
+			t.Error("expected yellow to be darkened on white")
+		}
+	})
+}
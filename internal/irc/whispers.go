@@ -0,0 +1,128 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/atye/ttchat/internal/terminal"
+	"github.com/atye/ttchat/internal/types"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// WhisperIRC is an IRC connection that also receives whispers
+type WhisperIRC interface {
+	OnWhisperMessage(func(types.WhisperMessage)) error
+}
+
+// WhisperSender sends a whisper to a user login. Twitch only accepts whispers through Helix.
+type WhisperSender interface {
+	SendWhisper(string, string) error // user login, message
+}
+
+const (
+	WhispersChannel = "Whispers"
+)
+
+type Whispers struct {
+	displayName string
+	sender      WhisperSender
+	upstream    chan types.Message
+	log         *log.Logger
+	mention     highlight
+	styles      Styles
+
+	mu   sync.Mutex
+	last string
+}
+
+var _ terminal.IRC = &Whispers{}
+var _ terminal.Whisperer = &Whispers{}
+
+func NewWhispers(irc WhisperIRC, sender WhisperSender, log *log.Logger, displayName string, styles Styles) *Whispers {
+	w := &Whispers{
+		displayName: displayName,
+		sender:      sender,
+		upstream:    make(chan types.Message),
+		log:         log,
+		mention:     mentionHighlight(displayName),
+		styles:      styles,
+	}
+
+	err := irc.OnWhisperMessage(func(incoming types.WhisperMessage) {
+		styled := incoming
+		styled.Channel = WhispersChannel
+		styled.Color = w.styles.userColor(styled.Login, styled.Color)
+		styled.Text, styled.Highlighted = styleText(styled.Text, "", []highlight{w.mention}, nil, w.styles)
+		styled.Name = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(styled.Color)).Render(styled.Name)
+
+		w.setLast(incoming.Conversation)
+		w.upstream <- styled
+	})
+	if err != nil {
+		w.log.Printf("irc: setting OnWhisperMessage behavior: %v\n", err)
+	}
+
+	return w
+}
+
+func (w *Whispers) IncomingMessages() <-chan types.Message {
+	return w.upstream
+}
+
+// Publish whispers msg to the user of the most recent conversation
+func (w *Whispers) Publish(msg string) {
+	w.mu.Lock()
+	to := w.last
+	w.mu.Unlock()
+
+	if to == "" {
+		go func() {
+			w.upstream <- types.WhisperMessage{PrivateMessage: w.styles.systemMessage(WhispersChannel, "use /w <user> <message> to start a conversation")}
+		}()
+		return
+	}
+	w.Whisper(to, msg)
+}
+
+// Reply whispers msg to the conversation parent belongs to
+func (w *Whispers) Reply(parent types.Message, msg string) {
+	if pm, ok := parent.(types.WhisperMessage); ok {
+		w.Whisper(pm.Conversation, msg)
+		return
+	}
+	w.Publish(msg)
+}
+
+func (w *Whispers) Whisper(to string, msg string) {
+	to = strings.ToLower(strings.TrimPrefix(to, "@"))
+	w.setLast(to)
+
+	go func() {
+		err := w.sender.SendWhisper(to, msg)
+		if err != nil {
+			w.log.Printf("irc: sending whisper to %s: %v\n", to, err)
+			w.upstream <- types.WhisperMessage{
+				PrivateMessage: w.styles.systemMessage(WhispersChannel, fmt.Sprintf("whisper to %s failed: %v", to, err)),
+				Conversation:   to,
+			}
+			return
+		}
+
+		w.upstream <- types.WhisperMessage{
+			PrivateMessage: types.PrivateMessage{
+				Channel: WhispersChannel,
+				Name:    w.styles.Mention.Render(w.displayName),
+				Text:    msg,
+			},
+			Conversation: to,
+		}
+	}()
+}
+
+func (w *Whispers) setLast(conversation string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = conversation
+}
@@ -0,0 +1,99 @@
+package irc
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/atye/ttchat/internal/types"
+)
+
+type mockWhisperIrc struct {
+	callback func(types.WhisperMessage)
+}
+
+func (i *mockWhisperIrc) OnWhisperMessage(f func(types.WhisperMessage)) error {
+	i.callback = f
+	return nil
+}
+
+type mockSender struct {
+	to  string
+	msg string
+	err error
+}
+
+func (s *mockSender) SendWhisper(to string, msg string) error {
+	s.to = to
+	s.msg = msg
+	return s.err
+}
+
+func TestWhispers(t *testing.T) {
+	t.Run("incoming", func(t *testing.T) {
+		incomingIRC := &mockWhisperIrc{}
+		w := NewWhispers(incomingIRC, &mockSender{}, log.New(io.Discard, "", 0), "user", DefaultStyles())
+
+		s := w.IncomingMessages()
+		go incomingIRC.callback(types.WhisperMessage{PrivateMessage: types.PrivateMessage{Name: "Foo", Text: "hi"}, Conversation: "foo"})
+
+		m := (<-s).(types.WhisperMessage)
+		if m.GetChannel() != WhispersChannel {
+			t.Errorf("expected channel %s, got %s", WhispersChannel, m.GetChannel())
+		}
+		if m.Conversation != "foo" {
+			t.Errorf("expected conversation foo, got %s", m.Conversation)
+		}
+	})
+
+	t.Run("publish answers last conversation", func(t *testing.T) {
+		incomingIRC := &mockWhisperIrc{}
+		sender := &mockSender{}
+		w := NewWhispers(incomingIRC, sender, log.New(io.Discard, "", 0), "user", DefaultStyles())
+
+		s := w.IncomingMessages()
+		go incomingIRC.callback(types.WhisperMessage{PrivateMessage: types.PrivateMessage{Name: "Foo", Text: "hi"}, Conversation: "foo"})
+		<-s
+
+		w.Publish("hello")
+		m := (<-s).(types.WhisperMessage)
+
+		if sender.to != "foo" || sender.msg != "hello" {
+			t.Errorf("expected whisper hello to foo, got %s to %s", sender.msg, sender.to)
+		}
+		if m.Conversation != "foo" || m.GetText() != "hello" {
+			t.Errorf("expected echo hello in conversation foo, got %s in %s", m.GetText(), m.Conversation)
+		}
+	})
+
+	t.Run("whisper", func(t *testing.T) {
+		sender := &mockSender{}
+		w := NewWhispers(&mockWhisperIrc{}, sender, log.New(io.Discard, "", 0), "user", DefaultStyles())
+
+		s := w.IncomingMessages()
+		w.Whisper("@Bar", "hello")
+		m := (<-s).(types.WhisperMessage)
+
+		if sender.to != "bar" {
+			t.Errorf("expected whisper to bar, got %s", sender.to)
+		}
+		if m.Conversation != "bar" {
+			t.Errorf("expected conversation bar, got %s", m.Conversation)
+		}
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		sender := &mockSender{err: fmt.Errorf("missing scope")}
+		w := NewWhispers(&mockWhisperIrc{}, sender, log.New(io.Discard, "", 0), "user", DefaultStyles())
+
+		s := w.IncomingMessages()
+		w.Whisper("bar", "hello")
+		m := <-s
+
+		want := DefaultStyles().System.Render("whisper to bar failed: missing scope")
+		if m.GetText() != want {
+			t.Errorf("expected text %s, got %s", want, m.GetText())
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	OSC9   = "osc9"   // iTerm2, WezTerm, Windows Terminal
+	OSC777 = "osc777" // rxvt-unicode, foot, Ghostty
+
+	// CommandInterval is the least time between runs of the command
+	CommandInterval = 5 * time.Second
+)
+
+// Notifier rings the terminal bell, sends a desktop notification through the terminal
+// and runs a command when the user is mentioned
+type Notifier struct {
+	Bell     bool
+	Desktop  string // OSC9, OSC777 or empty for no desktop notification
+	Command  string // run with sh -c, the message in TTCHAT_CHANNEL, TTCHAT_USER and TTCHAT_MESSAGE
+	Interval time.Duration
+	Log      *log.Logger
+
+	start func(*exec.Cmd) error
+
+	mu      sync.Mutex
+	last    time.Time // when the command last ran
+	pending *exec.Cmd // the command waiting for the interval to pass
+}
+
+// New validates desktop and returns a Notifier running command at most once every CommandInterval
+func New(log *log.Logger, bell bool, desktop string, command string) (*Notifier, error) {
+	switch desktop {
+	case "", OSC9, OSC777:
+	default:
+		return nil, fmt.Errorf("unknown desktop notification %q, expected %q or %q", desktop, OSC9, OSC777)
+	}
+	return &Notifier{Bell: bell, Desktop: desktop, Command: command, Interval: CommandInterval, Log: log}, nil
+}
+
+// Notify tells the user about a message from name in channel. It returns the escape sequences
+// of the bell and desktop notification for the caller to write to the terminal with its output.
+func (n *Notifier) Notify(channel string, name string, text string) string {
+	channel, name, text = clean(channel), clean(name), clean(text)
+
+	var b strings.Builder
+	if n.Bell {
+		b.WriteString("\a")
+	}
+	switch n.Desktop {
+	case OSC9:
+		fmt.Fprintf(&b, "\x1b]9;%s in %s: %s\a", name, channel, text)
+	case OSC777:
+		fmt.Fprintf(&b, "\x1b]777;notify;%s in %s;%s\a", name, channel, text)
+	}
+
+	if n.Command != "" {
+		cmd := exec.Command("sh", "-c", n.Command)
+		cmd.Env = append(os.Environ(),
+			fmt.Sprintf("TTCHAT_CHANNEL=%s", channel),
+			fmt.Sprintf("TTCHAT_USER=%s", name),
+			fmt.Sprintf("TTCHAT_MESSAGE=%s", text),
+		)
+		n.run(cmd)
+	}
+	return b.String()
+}
+
+// run starts cmd unless the command ran less than the interval ago. Then cmd waits for the interval
+// to pass, replaced by the commands of later mentions so a burst of them runs the command once.
+func (n *Notifier) run(cmd *exec.Cmd) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.pending != nil {
+		n.pending = cmd
+		return
+	}
+	wait := n.Interval - time.Since(n.last)
+	if wait <= 0 {
+		n.last = time.Now()
+		go n.exec(cmd)
+		return
+	}
+
+	n.pending = cmd
+	time.AfterFunc(wait, func() {
+		n.mu.Lock()
+		cmd := n.pending
+		n.pending = nil
+		n.last = time.Now()
+		n.mu.Unlock()
+		n.exec(cmd)
+	})
+}
+
+func (n *Notifier) exec(cmd *exec.Cmd) {
+	start := n.start
+	if start == nil {
+		start = startAndWait
+	}
+	if err := start(cmd); err != nil {
+		n.Log.Printf("notify: running %q: %v\n", n.Command, err)
+	}
+}
+
+func startAndWait(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// clean removes styling and control characters that would end or break an escape sequence
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, ansi.Strip(s))
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		Name    string
+		bell    bool
+		desktop string
+		want    string
+	}{
+		{"none", false, "", ""},
+		{"bell", true, "", "\a"},
+		{"osc9", false, OSC9, "\x1b]9;foo in chess: hi @user\a"},
+		{"osc777", true, OSC777, "\a\x1b]777;notify;foo in chess;hi @user\a"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			n, err := New(log.New(io.Discard, "", 0), test.bell, test.desktop, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := n.Notify("chess", "\x1b[1mfoo\x1b[0m", "hi @user\a")
+			if got != test.want {
+				t.Errorf("expected output %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	t.Run("command", func(t *testing.T) {
+		n, err := New(log.New(io.Discard, "", 0), false, "", "notify-send \"$TTCHAT_USER\"")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmds := make(chan *exec.Cmd, 1)
+		n.start = func(cmd *exec.Cmd) error {
+			cmds <- cmd
+			return nil
+		}
+
+		n.Notify("chess", "foo", "hi @user")
+
+		cmd := <-cmds
+		if got := strings.Join(cmd.Args, " "); got != "sh -c notify-send \"$TTCHAT_USER\"" {
+			t.Errorf("expected command sh -c notify-send \"$TTCHAT_USER\", got %s", got)
+		}
+		env := strings.Join(cmd.Env, "\n")
+		for _, want := range []string{"TTCHAT_CHANNEL=chess", "TTCHAT_USER=foo", "TTCHAT_MESSAGE=hi @user"} {
+			if !strings.Contains(env, want) {
+				t.Errorf("expected environment to contain %s", want)
+			}
+		}
+	})
+
+	t.Run("command burst", func(t *testing.T) {
+		n, err := New(log.New(io.Discard, "", 0), false, "", "true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.Interval = 50 * time.Millisecond
+		cmds := make(chan *exec.Cmd, 10)
+		n.start = func(cmd *exec.Cmd) error {
+			cmds <- cmd
+			return nil
+		}
+
+		for _, text := range []string{"one", "two", "three"} {
+			n.Notify("chess", "foo", text)
+		}
+
+		for _, want := range []string{"one", "three"} {
+			select {
+			case cmd := <-cmds:
+				if !strings.Contains(strings.Join(cmd.Env, "\n"), "TTCHAT_MESSAGE="+want) {
+					t.Errorf("expected the command to run for %s", want)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected the command to run for %s", want)
+			}
+		}
+		select {
+		case cmd := <-cmds:
+			t.Errorf("expected two runs, got another with %v", cmd.Env[len(cmd.Env)-1])
+		case <-time.After(2 * n.Interval):
+		}
+	})
+
+	t.Run("unknown desktop", func(t *testing.T) {
+		_, err := New(log.Default(), false, "growl", "")
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
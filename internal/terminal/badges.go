@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Badge is how a Twitch badge, such as moderator, is shown before a user's name
+type Badge struct {
+	Label  string `yaml:"label"`
+	Color  string `yaml:"color"`
+	Hidden bool   `yaml:"hidden"`
+}
+
+var (
+	DefaultBadges = map[string]Badge{
+		"broadcaster": {Label: "B", Color: "#E91916"},
+		"staff":       {Label: "T", Color: "#000000"},
+		"admin":       {Label: "A", Color: "#FAAF19"},
+		"global_mod":  {Label: "G", Color: "#006400"},
+		"moderator":   {Label: "M", Color: "#00AD03"},
+		"vip":         {Label: "V", Color: "#E005B9"},
+		"founder":     {Label: "F", Color: "#A970FF"},
+		"subscriber":  {Label: "S", Color: "#8205B4"},
+		"partner":     {Label: "✓", Color: "#9146FF"},
+	}
+
+	// badges are shown in this order, followed by others alphabetically
+	badgeOrder = []string{"broadcaster", "staff", "admin", "global_mod", "moderator", "vip", "founder", "subscriber", "partner"}
+
+	badgeStyle = lipgloss.NewStyle().Bold(true).Padding(0, 1).Foreground(lipgloss.Color("#FFFFFF"))
+)
+
+// MergeBadges returns DefaultBadges changed by overrides. An override without a label or color keeps the default's.
+func MergeBadges(overrides map[string]Badge) map[string]Badge {
+	badges := make(map[string]Badge, len(DefaultBadges)+len(overrides))
+	for k, b := range DefaultBadges {
+		badges[k] = b
+	}
+	for k, o := range overrides {
+		b := badges[k]
+		if o.Label != "" {
+			b.Label = o.Label
+		}
+		if o.Color != "" {
+			b.Color = o.Color
+		}
+		b.Hidden = o.Hidden
+		badges[k] = b
+	}
+	return badges
+}
+
+// WithBadges shows badges before user names
+func WithBadges(badges map[string]Badge) ChannelOption {
+	return func(c *Channel) {
+		c.badges = badges
+	}
+}
+
+// renderBadges returns the styled labels of the shown badges in userBadges, followed by a space
+func (c *Channel) renderBadges(userBadges map[string]int) string {
+	if len(c.badges) == 0 || len(userBadges) == 0 {
+		return ""
+	}
+
+	var names []string
+	for name := range userBadges {
+		if b, ok := c.badges[name]; ok && !b.Hidden && b.Label != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := badgePriority(names[i]), badgePriority(names[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for _, name := range names {
+		badge := c.badges[name]
+		style := badgeStyle
+		if badge.Color != "" {
+			style = style.Background(lipgloss.Color(badge.Color))
+		}
+		b.WriteString(style.Render(badge.Label))
+		b.WriteString(" ")
+	}
+	return b.String()
+}
+
+func badgePriority(name string) int {
+	for i, n := range badgeOrder {
+		if n == name {
+			return i
+		}
+	}
+	return len(badgeOrder)
+}
@@ -0,0 +1,76 @@
+package terminal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestMergeBadges(t *testing.T) {
+	tests := []struct {
+		Name      string
+		overrides map[string]Badge
+		badge     string
+		want      Badge
+	}{
+		{"default", nil, "moderator", Badge{Label: "M", Color: "#00AD03"}},
+		{"label", map[string]Badge{"vip": {Label: "★"}}, "vip", Badge{Label: "★", Color: "#E005B9"}},
+		{"color", map[string]Badge{"vip": {Color: "#123456"}}, "vip", Badge{Label: "V", Color: "#123456"}},
+		{"hidden keeps the label", map[string]Badge{"subscriber": {Hidden: true}}, "subscriber", Badge{Label: "S", Color: "#8205B4", Hidden: true}},
+		{"unknown type", map[string]Badge{"bits": {Label: "$", Color: "#FFD700"}}, "bits", Badge{Label: "$", Color: "#FFD700"}},
+		{"unknown type without a label", map[string]Badge{"bits": {Color: "#FFD700"}}, "bits", Badge{Color: "#FFD700"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := MergeBadges(test.overrides)
+			if got[test.badge] != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, got[test.badge])
+			}
+		})
+	}
+
+	t.Run("defaults are unchanged", func(t *testing.T) {
+		MergeBadges(map[string]Badge{"moderator": {Label: "X", Hidden: true}})
+		if want := (Badge{Label: "M", Color: "#00AD03"}); DefaultBadges["moderator"] != want {
+			t.Errorf("expected %+v, got %+v", want, DefaultBadges["moderator"])
+		}
+	})
+}
+
+func TestRenderBadges(t *testing.T) {
+	tests := []struct {
+		Name       string
+		overrides  map[string]Badge
+		userBadges map[string]int
+		want       []string // labels in order
+	}{
+		{"none", nil, nil, nil},
+		{"known order", nil, map[string]int{"subscriber": 12, "vip": 1, "broadcaster": 1}, []string{"B", "V", "S"}},
+		{"moderator before founder", nil, map[string]int{"founder": 0, "moderator": 1}, []string{"M", "F"}},
+		{"unknown after known, alphabetically", map[string]Badge{"zeta": {Label: "Z"}, "bits": {Label: "$"}}, map[string]int{"zeta": 1, "bits": 100, "partner": 1}, []string{"✓", "$", "Z"}},
+		{"hidden", map[string]Badge{"subscriber": {Hidden: true}}, map[string]int{"subscriber": 12, "vip": 1}, []string{"V"}},
+		{"without a label", map[string]Badge{"bits": {Color: "#FFD700"}}, map[string]int{"bits": 100}, nil},
+		{"not configured", nil, map[string]int{"glhf-pledge": 1}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := NewChannel(&mockIRC{}, "chess", 0, WithBadges(MergeBadges(test.overrides)))
+
+			got := strings.Fields(ansi.Strip(c.renderBadges(test.userBadges)))
+			if !reflect.DeepEqual(got, test.want) && (len(got) != 0 || len(test.want) != 0) {
+				t.Errorf("expected badges %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	t.Run("without badges", func(t *testing.T) {
+		c := NewChannel(&mockIRC{}, "chess", 0)
+		if got := c.renderBadges(map[string]int{"moderator": 1}); got != "" {
+			t.Errorf("expected no badges, got %q", got)
+		}
+	})
+}
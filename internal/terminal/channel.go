@@ -5,98 +5,350 @@ import (
 	"strings"
 
 	"github.com/atye/ttchat/internal/types"
-	"github.com/muesli/reflow/wordwrap"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 type IRC interface {
 	IncomingMessages() <-chan types.Message
 	Publish(string)
+	Reply(types.Message, string) // parent message, message
+}
+
+// Whisperer sends whispers from any tab with /w
+type Whisperer interface {
+	Whisper(string, string) // user login, message
+}
+
+// Completer suggests words, such as emote codes, for a prefix typed in the input
+type Completer interface {
+	Complete(prefix string) []string
 }
 
 type Channel struct {
-	name        string
-	incomingMsg <-chan types.Message
-	lines       []line
-	irc         IRC
-	width       int
-	lineSpacing int
+	name           string
+	incomingMsg    <-chan types.Message
+	messages       []message
+	lines          []line
+	irc            IRC
+	width          int
+	height         int
+	lineSpacing    int
+	completer      Completer
+	lastID         int
+	selected       int
+	group          func(types.Message) string
+	bans           map[string][]types.ClearChat
+	unread         int // messages since the tab was last viewed
+	unreadMentions int // mentions since the tab was last viewed
+	timestamps     string
+	badges         map[string]Badge
+	scroll         int // lines scrolled up from the newest
+	hyperlinks     bool
+	timestampStyle lipgloss.Style
+}
+
+type message struct {
+	id  int
+	msg types.Message
+}
+
+type ChannelOption func(*Channel)
+
+const (
+	maxMessages = 500
+)
+
+var (
+	replyStyle  = lipgloss.NewStyle().Faint(true)
+	groupStyle  = lipgloss.NewStyle().Bold(true).Faint(true)
+	sourceStyle = lipgloss.NewStyle().Faint(true)
+
+	timestampStyle = lipgloss.NewStyle().Faint(true)
+)
+
+func WithCompleter(completer Completer) ChannelOption {
+	return func(c *Channel) {
+		c.completer = completer
+	}
+}
+
+// WithTimestamps shows the time of each message formatted with layout, like "15:04"
+func WithTimestamps(layout string) ChannelOption {
+	return func(c *Channel) {
+		c.timestamps = layout
+	}
+}
+
+// WithHyperlinks makes URLs in messages clickable in terminals supporting OSC 8
+func WithHyperlinks() ChannelOption {
+	return func(c *Channel) {
+		c.hyperlinks = true
+	}
+}
+
+// WithGrouping renders messages in blocks by the key group returns,
+// ordered by each block's most recent message
+func WithGrouping(group func(types.Message) string) ChannelOption {
+	return func(c *Channel) {
+		c.group = group
+	}
 }
 
-func NewChannel(irc IRC, name string, lineSpacing int) *Channel {
-	return &Channel{
+func NewChannel(irc IRC, name string, lineSpacing int, opts ...ChannelOption) *Channel {
+	c := &Channel{
 		name:        name,
 		incomingMsg: irc.IncomingMessages(),
 		irc:         irc,
 		lineSpacing: lineSpacing,
+		bans:        make(map[string][]types.ClearChat),
+
+		timestampStyle: timestampStyle,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
-func (c *Channel) initLines(lines int) {
-	c.lines = make([]line, lines)
-	for i := 0; i < len(c.lines); i++ {
-		c.lines[i] = line{value: "\n"}
+func (c *Channel) complete(prefix string) []string {
+	if c.completer == nil {
+		return nil
 	}
+	return c.completer.Complete(prefix)
 }
 
-func (c *Channel) update(msg types.Message) {
-	for i := 0; i < c.lineSpacing; i++ {
-		c.lines = append(c.lines[1:], line{value: "\n"})
+// update adds msg to the channel and returns its id
+func (c *Channel) update(msg types.Message) int {
+	if cc, ok := msg.(types.ClearChat); ok && cc.Target != "" {
+		login := strings.ToLower(cc.Target)
+		c.bans[login] = append(c.bans[login], cc)
 	}
 
-	msgLines := strings.Split(wordwrap.String(fmt.Sprintf("%s: %s", msg.GetName(), msg.GetText()), c.width), "\n")
+	c.lastID++
+	m := message{id: c.lastID, msg: msg}
 
-	newLines := make([]line, len(msgLines))
-	for i := 0; i < len(msgLines); i++ {
-		newLines[i] = line{author: msg.GetName(), value: fmt.Sprintf("%s\n", msgLines[i])}
+	c.messages = append(c.messages, m)
+	if len(c.messages) > maxMessages {
+		c.messages = c.messages[len(c.messages)-maxMessages:]
+	}
+
+	if c.group != nil {
+		c.resize(c.height, c.width)
+		return m.id
+	}
+	rendered := c.render(m)
+	if c.scroll > 0 {
+		// keep showing the same lines while scrolled up, the new ones are below them
+		c.scroll += len(rendered)
+		return m.id
 	}
-	c.lines = append(c.lines[len(newLines):], newLines...)
+	c.lines = c.fit(append(c.lines, rendered...))
+	return m.id
 }
 
+// resize re-renders the most recent messages to fill height lines of width
 func (c *Channel) resize(height int, width int) {
-	newLines := make([]line, height)
-	newLinesIndex := len(newLines) - 1
-	linesIndex := len(c.lines) - 1
+	c.height = height
+	c.width = width
+	c.lines = c.window()
+}
 
-	for i := 0; i < len(newLines); i++ {
-		newLines[i] = line{value: "\n"}
-	}
+// scrollBy moves the view n lines back in history, or forward when n is negative
+func (c *Channel) scrollBy(n int) {
+	c.scroll = max(0, c.scroll+n)
+	c.lines = c.window()
+}
 
-out:
-	for linesIndex >= 0 {
-		if newLinesIndex < 0 {
-			break
+// window renders the height lines ending scroll lines before the newest
+func (c *Channel) window() []line {
+	var lines []line
+	if c.group != nil {
+		lines = c.renderGroups()
+	} else {
+		for i := len(c.messages) - 1; i >= 0 && len(lines) < c.height+c.scroll; i-- {
+			lines = append(c.render(c.messages[i]), lines...)
 		}
+	}
+
+	maxScroll := 0
+	if c.height > 1 && len(lines) > c.height {
+		// the marker below the lines takes one row, so there's nothing to scroll without room for both
+		maxScroll = min(len(lines), len(lines)-c.rows())
+	}
+	c.scroll = min(c.scroll, maxScroll)
+	return c.fit(lines[:len(lines)-c.scroll])
+}
+
+// rows is the number of lines shown, leaving a row for the scroll marker while scrolled up
+func (c *Channel) rows() int {
+	if c.scroll > 0 {
+		return c.height - 1
+	}
+	return c.height
+}
+
+// ordered returns the messages in the order they are displayed
+func (c *Channel) ordered() []message {
+	if c.group == nil {
+		return c.messages
+	}
 
-		author := c.lines[linesIndex].author
-		var buf []string
-		if author != "" {
-			for j := linesIndex; j >= 0; j-- {
-				if c.lines[j].author == author {
-					buf = append([]string{strings.Replace(c.lines[j].value, "\n", "", -1)}, buf...)
-					linesIndex--
-				} else {
+	var keys []string
+	groups := make(map[string][]message)
+	for _, m := range c.messages {
+		k := c.group(m.msg)
+		if _, ok := groups[k]; ok {
+			for i, key := range keys {
+				if key == k {
+					keys = append(keys[:i], keys[i+1:]...)
 					break
 				}
 			}
-		} else {
-			buf = []string{strings.Replace(c.lines[linesIndex].value, "\n", "", -1)}
-			linesIndex--
 		}
+		keys = append(keys, k)
+		groups[k] = append(groups[k], m)
+	}
 
-		msgLines := strings.Split(wordwrap.String(strings.Join(buf, " "), width), "\n")
-		if len(msgLines) == 1 {
-			newLines[newLinesIndex] = line{author: author, value: fmt.Sprintf("%s\n", msgLines[0])}
-			newLinesIndex--
-		} else {
-			for j := len(msgLines) - 1; j >= 0; j-- {
-				if newLinesIndex < 0 {
-					break out
-				}
-				newLines[newLinesIndex] = line{author: author, value: fmt.Sprintf("%s\n", msgLines[j])}
-				newLinesIndex--
+	ordered := make([]message, 0, len(c.messages))
+	for _, k := range keys {
+		ordered = append(ordered, groups[k]...)
+	}
+	return ordered
+}
+
+func (c *Channel) renderGroups() []line {
+	var lines []line
+	key := ""
+	for i, m := range c.ordered() {
+		if k := c.group(m.msg); i == 0 || k != key {
+			key = k
+			if k != "" {
+				lines = append(lines, line{value: fmt.Sprintf("%s\n", groupStyle.Render(fmt.Sprintf("── @%s ──", k)))})
 			}
 		}
+		lines = append(lines, c.render(m)...)
 	}
-	c.lines = newLines
-	c.width = width
+	return lines
+}
+
+// render returns the wrapped lines of a message, preceded by line spacing
+func (c *Channel) render(m message) []line {
+	var lines []line
+	for i := 0; i < c.lineSpacing; i++ {
+		lines = append(lines, line{value: "\n"})
+	}
+
+	if r := m.msg.GetReply(); r != nil {
+		context := fmt.Sprintf("↳ replying to @%s: %s", r.ParentName, r.ParentText)
+		if c.width > 0 {
+			context = ansi.Truncate(context, c.width, "…")
+		}
+		lines = append(lines, line{msgID: m.id, value: fmt.Sprintf("%s\n", replyStyle.Render(context))})
+	}
+
+	prefix := c.renderBadges(m.msg.GetBadges())
+	if source := m.msg.GetSource(); source != "" {
+		prefix = fmt.Sprintf("%s %s", sourceStyle.Render(fmt.Sprintf("#%s", source)), prefix)
+	}
+	if t := m.msg.GetTime(); c.timestamps != "" && !t.IsZero() {
+		prefix = fmt.Sprintf("%s %s", c.timestampStyle.Render(t.Format(c.timestamps)), prefix)
+	}
+	name := m.msg.GetName()
+
+	text := fmt.Sprintf("%s%s: %s", prefix, name, m.msg.GetText())
+	if c.hyperlinks {
+		text = hyperlink(text)
+	}
+	for i, l := range wrap(text, c.width) {
+		ln := line{msgID: m.id, value: fmt.Sprintf("%s\n", l)}
+		if i == 0 {
+			ln.nameStart = ansi.StringWidth(prefix)
+			ln.nameEnd = ln.nameStart + ansi.StringWidth(name)
+		}
+		lines = append(lines, ln)
+	}
+	return lines
+}
+
+// fit pads or trims lines from the top so there are exactly height of them
+func (c *Channel) fit(lines []line) []line {
+	rows := c.rows()
+	if rows <= 0 {
+		return nil
+	}
+	if len(lines) > rows {
+		return lines[len(lines)-rows:]
+	}
+
+	fitted := make([]line, rows-len(lines), rows)
+	for i := range fitted {
+		fitted[i] = line{value: "\n"}
+	}
+	return append(fitted, lines...)
+}
+
+func (c *Channel) visible(id int) bool {
+	for _, l := range c.lines {
+		if l.msgID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPrevious moves the selection to the previous visible message, starting from the newest
+func (c *Channel) selectPrevious() {
+	messages := c.ordered()
+	i := len(messages) - 1
+	if c.selected != 0 {
+		i = c.position(messages) - 1
+	}
+	if i >= 0 && c.visible(messages[i].id) {
+		c.selected = messages[i].id
+	}
+}
+
+func (c *Channel) selectNext() {
+	if c.selected == 0 {
+		return
+	}
+	messages := c.ordered()
+	if i := c.position(messages) + 1; i > 0 && i < len(messages) {
+		c.selected = messages[i].id
+	}
+}
+
+func (c *Channel) position(messages []message) int {
+	for i, m := range messages {
+		if m.id == c.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Channel) markRead() {
+	c.unread = 0
+	c.unreadMentions = 0
+}
+
+func (c *Channel) clearSelection() {
+	c.selected = 0
+}
+
+func (c *Channel) selectedMessage() (types.Message, bool) {
+	return c.message(c.selected)
+}
+
+func (c *Channel) message(id int) (types.Message, bool) {
+	if id == 0 {
+		return nil, false
+	}
+	for _, m := range c.messages {
+		if m.id == id {
+			return m.msg, true
+		}
+	}
+	return nil, false
 }
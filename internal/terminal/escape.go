@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// escapeDelay keeps an escape sequence in the View for a few frames of the renderer,
+	// which only writes the latest View each frame
+	escapeDelay = 50 * time.Millisecond
+)
+
+// escapedMsg removes the escape sequences up to the nth from the View
+type escapedMsg int
+
+// emit writes seq, such as the terminal bell, to the terminal at the start of the View.
+// Writing to the terminal outside the View would race the renderer and could corrupt a frame.
+func (m *Model) emit(seq string) tea.Cmd {
+	if seq == "" {
+		return nil
+	}
+	m.escapes = append(m.escapes, seq)
+	n := m.escaped + len(m.escapes)
+	return tea.Tick(escapeDelay, func(time.Time) tea.Msg {
+		return escapedMsg(n)
+	})
+}
+
+// updateEscaped removes the sequences written by the renderer
+func (m *Model) updateEscaped(n escapedMsg) {
+	drop := min(int(n)-m.escaped, len(m.escapes))
+	if drop <= 0 {
+		return
+	}
+	m.escapes = m.escapes[drop:]
+	m.escaped += drop
+}
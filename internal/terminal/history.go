@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// History recalls the messages sent in each tab across sessions
+type History interface {
+	Add(string, string)      // channel, message
+	Entries(string) []string // channel, oldest first
+	Save() error
+}
+
+const (
+	maxMessageLength = 500 // Twitch's limit in characters
+)
+
+var (
+	counterStyle     = lipgloss.NewStyle().Faint(true)
+	overLimitStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#E91916"))
+	defaultRecalling = -1
+)
+
+// WithHistory recalls sent messages with the history keys and remembers new ones
+func WithHistory(history History) ModelOption {
+	return func(m *Model) {
+		m.history = history
+	}
+}
+
+// remember adds v, sent in the active tab, to the history and saves it in the background
+func (m *Model) remember(v string) tea.Cmd {
+	m.recalling = defaultRecalling
+	if m.history == nil {
+		return nil
+	}
+	m.history.Add(m.channels[m.activeChannel].name, v)
+
+	history, log := m.history, m.log
+	return func() tea.Msg {
+		if err := history.Save(); err != nil {
+			log.Printf("history: %v\n", err)
+		}
+		return nil
+	}
+}
+
+// recall replaces the input with an older (step -1) or newer (step 1) message sent in the active tab,
+// returning to what was being typed after the newest
+func (m *Model) recall(step int) {
+	if m.history == nil {
+		return
+	}
+	entries := m.history.Entries(m.channels[m.activeChannel].name)
+	if len(entries) == 0 {
+		return
+	}
+
+	i := m.recalling
+	if i == defaultRecalling {
+		if step > 0 {
+			return
+		}
+		m.draft = m.textInput.Value()
+		i = len(entries)
+	}
+	i += step
+	switch {
+	case i < 0:
+		return
+	case i >= len(entries):
+		m.recalling = defaultRecalling
+		m.textInput.SetValue(m.draft)
+	default:
+		m.recalling = i
+		m.textInput.SetValue(entries[i])
+	}
+	m.textInput.CursorEnd()
+	m.updateSuggestions()
+}
+
+// counter is the length of the input against Twitch's limit
+func (m *Model) counter() string {
+	n := utf8.RuneCountInString(m.textInput.Value())
+	if n == 0 {
+		return ""
+	}
+	c := fmt.Sprintf("%d/%d", n, maxMessageLength)
+	if n > maxMessageLength {
+		return overLimitStyle.Render(fmt.Sprintf("%s too long", c))
+	}
+	return counterStyle.Render(c)
+}
@@ -0,0 +1,107 @@
+package terminal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mockHistory struct {
+	entries map[string][]string
+	saves   int
+}
+
+func (h *mockHistory) Add(channel string, text string) {
+	h.entries[channel] = append(h.entries[channel], text)
+}
+
+func (h *mockHistory) Entries(channel string) []string {
+	return h.entries[channel]
+}
+
+func (h *mockHistory) Save() error {
+	h.saves++
+	return nil
+}
+
+func TestRecall(t *testing.T) {
+	tests := []struct {
+		Name          string
+		keys          []string
+		wantInput     string
+		wantRecalling int
+	}{
+		{"previous", []string{"up"}, "three", 2},
+		{"stops at the oldest", []string{"up", "up", "up", "up"}, "one", 0},
+		{"newer", []string{"up", "up", "down"}, "three", 2},
+		{"back to the draft", []string{"up", "up", "down", "down"}, "dra", defaultRecalling},
+		{"newer without recalling", []string{"down"}, "dra", defaultRecalling},
+		{"another tab", []string{"up", "tab", "up"}, "three", defaultRecalling},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			history := &mockHistory{entries: map[string][]string{"chess": {"one", "two", "three"}}}
+			m, _ := newTestModel([]string{"chess", "other"}, WithHistory(history))
+			press(m, "dra")
+
+			press(m, test.keys...)
+
+			if m.textInput.Value() != test.wantInput {
+				t.Errorf("expected input %q, got %q", test.wantInput, m.textInput.Value())
+			}
+			if m.recalling != test.wantRecalling {
+				t.Errorf("expected to recall entry %d, got %d", test.wantRecalling, m.recalling)
+			}
+		})
+	}
+
+	t.Run("sending remembers the message", func(t *testing.T) {
+		history := &mockHistory{entries: map[string][]string{"chess": {"one"}}}
+		m, ircs := newTestModel([]string{"chess"}, WithHistory(history))
+
+		press(m, "up", "enter")
+
+		if want := []string{"one", "one"}; !reflect.DeepEqual(history.entries["chess"], want) {
+			t.Errorf("expected entries %v, got %v", want, history.entries["chess"])
+		}
+		if got := ircs["chess"].published; len(got) != 1 || got[0] != "one" {
+			t.Errorf("expected one to be published, got %v", got)
+		}
+		if m.recalling != defaultRecalling || m.textInput.Value() != "" {
+			t.Errorf("expected an empty input, got %q recalling %d", m.textInput.Value(), m.recalling)
+		}
+	})
+
+	t.Run("saved in the background", func(t *testing.T) {
+		history := &mockHistory{entries: map[string][]string{}}
+		m, _ := newTestModel([]string{"chess"}, WithHistory(history))
+
+		cmd := m.remember("hi")
+		if history.saves != 0 {
+			t.Fatalf("expected no save before the command runs, got %d", history.saves)
+		}
+		cmd()
+		if history.saves != 1 {
+			t.Errorf("expected 1 save, got %d", history.saves)
+		}
+	})
+}
+
+func TestCounter(t *testing.T) {
+	m, _ := newTestModel([]string{"chess"})
+	if got := m.counter(); got != "" {
+		t.Errorf("expected no counter for an empty input, got %q", got)
+	}
+
+	press(m, "hello")
+	if got := m.counter(); !strings.Contains(got, "5/500") {
+		t.Errorf("expected 5/500, got %q", got)
+	}
+
+	m.textInput.CharLimit = 0
+	m.textInput.SetValue(strings.Repeat("a", 501))
+	if got := m.counter(); !strings.Contains(got, "501/500 too long") {
+		t.Errorf("expected 501/500 too long, got %q", got)
+	}
+}
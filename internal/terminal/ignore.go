@@ -0,0 +1,75 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// IgnoreList is changed with the /ignore and /unignore commands
+type IgnoreList interface {
+	Ignore(string, string, string) error   // user login, user ID or empty, channel or empty for every channel
+	Unignore(string, string, string) error // user login, user ID or empty, channel or empty for every channel
+}
+
+// UserIDs finds the user ID of a login. A user lookup implementing it lets the ignore list
+// follow users who rename and unignore them by their new name.
+type UserIDs interface {
+	UserID(string) (string, error)
+}
+
+// ignoredMsg is the status after the ignore list changed
+type ignoredMsg string
+
+// WithIgnoreList enables the /ignore and /unignore commands
+func WithIgnoreList(list IgnoreList) ModelOption {
+	return func(m *Model) {
+		m.ignoreList = list
+	}
+}
+
+// ignore runs "/ignore <user> [#channel]" and "/unignore <user> [#channel]", looking up the user's ID
+// in the background
+func (m *Model) ignore(v string) (tea.Cmd, bool) {
+	fields := strings.Fields(v)
+	if len(fields) < 2 || len(fields) > 3 || (fields[0] != "/ignore" && fields[0] != "/unignore") {
+		return nil, false
+	}
+	if m.ignoreList == nil {
+		m.status = "no ignore list configured"
+		return nil, true
+	}
+
+	login := strings.TrimPrefix(fields[1], "@")
+	var channel string
+	where := "everywhere"
+	if len(fields) == 3 {
+		channel = strings.TrimPrefix(fields[2], "#")
+		where = fmt.Sprintf("in #%s", channel)
+	}
+
+	list := m.ignoreList
+	ids, _ := m.lookup.(UserIDs)
+	command := fields[0]
+	return func() tea.Msg {
+		var id string
+		if ids != nil {
+			// without the ID the user is ignored by login only
+			id, _ = ids.UserID(login)
+		}
+
+		var err error
+		status := fmt.Sprintf("ignoring @%s %s", login, where)
+		if command == "/ignore" {
+			err = list.Ignore(login, id, channel)
+		} else {
+			err = list.Unignore(login, id, channel)
+			status = fmt.Sprintf("no longer ignoring @%s %s", login, where)
+		}
+		if err != nil {
+			status = fmt.Sprintf("%s: %v", command, err)
+		}
+		return ignoredMsg(status)
+	}, true
+}
@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type mockIgnoreList struct {
+	calls []string
+	err   error
+}
+
+func (l *mockIgnoreList) Ignore(login string, userID string, channel string) error {
+	l.calls = append(l.calls, fmt.Sprintf("ignore %s %s %s", login, userID, channel))
+	return l.err
+}
+
+func (l *mockIgnoreList) Unignore(login string, userID string, channel string) error {
+	l.calls = append(l.calls, fmt.Sprintf("unignore %s %s %s", login, userID, channel))
+	return l.err
+}
+
+type mockUserIDs struct {
+	mockLookup
+	ids map[string]string
+}
+
+func (l *mockUserIDs) UserID(login string) (string, error) {
+	id, ok := l.ids[login]
+	if !ok {
+		return "", fmt.Errorf("user %s not found", login)
+	}
+	return id, nil
+}
+
+func TestIgnore(t *testing.T) {
+	tests := []struct {
+		Name       string
+		command    string
+		lookup     UserLookup
+		err        error
+		wantCalls  []string
+		wantStatus string
+	}{
+		{"everywhere", "/ignore @foo", nil, nil, []string{"ignore foo  "}, "ignoring @foo everywhere"},
+		{"channel", "/ignore foo #chess", nil, nil, []string{"ignore foo  chess"}, "ignoring @foo in #chess"},
+		{"user id", "/ignore foo", &mockUserIDs{ids: map[string]string{"foo": "123"}}, nil, []string{"ignore foo 123 "}, "ignoring @foo everywhere"},
+		{"unknown user id", "/ignore foo", &mockUserIDs{}, nil, []string{"ignore foo  "}, "ignoring @foo everywhere"},
+		{"lookup without ids", "/ignore foo", &mockLookup{}, nil, []string{"ignore foo  "}, "ignoring @foo everywhere"},
+		{"unignore renamed", "/unignore newname", &mockUserIDs{ids: map[string]string{"newname": "123"}}, nil, []string{"unignore newname 123 "}, "no longer ignoring @newname everywhere"},
+		{"error", "/unignore foo #chess", nil, fmt.Errorf("disk full"), []string{"unignore foo  chess"}, "/unignore: disk full"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			list := &mockIgnoreList{err: test.err}
+			opts := []ModelOption{WithIgnoreList(list)}
+			if test.lookup != nil {
+				opts = append(opts, WithUserLookup(test.lookup))
+			}
+			m, ircs := newTestModel([]string{"chess"}, opts...)
+
+			cmd, ok := m.ignore(test.command)
+			if !ok || cmd == nil {
+				t.Fatalf("expected %q to be an ignore command", test.command)
+			}
+			m.Update(cmd())
+
+			if !reflect.DeepEqual(list.calls, test.wantCalls) {
+				t.Errorf("expected calls %q, got %q", test.wantCalls, list.calls)
+			}
+			if m.status != test.wantStatus {
+				t.Errorf("expected status %q, got %q", test.wantStatus, m.status)
+			}
+			if len(ircs["chess"].published) != 0 {
+				t.Errorf("expected nothing published, got %v", ircs["chess"].published)
+			}
+		})
+	}
+
+	t.Run("not a command", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"}, WithIgnoreList(&mockIgnoreList{}))
+		for _, v := range []string{"/ignore", "/ignore a b c", "ignore foo"} {
+			if _, ok := m.ignore(v); ok {
+				t.Errorf("expected %q not to be an ignore command", v)
+			}
+		}
+	})
+
+	t.Run("no list", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+
+		press(m, "/ignore foo", "enter")
+
+		if m.status != "no ignore list configured" || len(ircs["chess"].published) != 0 {
+			t.Errorf("expected the missing list in the status, got %q", m.status)
+		}
+	})
+}
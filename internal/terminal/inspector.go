@@ -0,0 +1,204 @@
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atye/ttchat/internal/duration"
+	"github.com/atye/ttchat/internal/types"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// UserLookup fetches what Twitch knows about a user of a channel
+type UserLookup interface {
+	LookupUser(string, string) (types.UserInfo, error) // channel, user login
+}
+
+// inspector shows what is known about login to help moderators decide on an action
+type inspector struct {
+	channel *Channel
+	login   string
+	info    *types.UserInfo
+	err     error
+}
+
+type userInfoMsg struct {
+	login string
+	info  types.UserInfo
+	err   error
+}
+
+const (
+	inspectorMessages = 10
+	dateLayout        = "2006-01-02"
+	timeLayout        = "15:04"
+)
+
+var (
+	labelStyle = lipgloss.NewStyle().Bold(true)
+)
+
+// WithUserLookup shows account and follow age in the user inspector
+func WithUserLookup(lookup UserLookup) ModelOption {
+	return func(m *Model) {
+		m.lookup = lookup
+	}
+}
+
+func (m *Model) inspect(login string) tea.Cmd {
+	login = strings.ToLower(strings.TrimPrefix(login, "@"))
+	if login == "" {
+		return nil
+	}
+
+	ch := m.channels[m.activeChannel]
+	m.inspecting = &inspector{channel: ch, login: login}
+	if m.lookup == nil {
+		return nil
+	}
+
+	lookup := m.lookup
+	return func() tea.Msg {
+		info, err := lookup.LookupUser(ch.name, login)
+		return userInfoMsg{login: login, info: info, err: err}
+	}
+}
+
+func (m *Model) updateInspector(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Cancel, m.keys.Inspect), msg.String() == "q":
+		m.inspecting = nil
+	case key.Matches(msg, m.keys.Timeout, m.keys.Ban, m.keys.Unban):
+		m.moderateUser(m.moderation(msg), m.inspecting.login, "", "")
+	}
+	return m, listenForMessages(m)
+}
+
+// userMessages returns the most recent chat messages of login in the channel
+func (c *Channel) userMessages(login string, n int) []types.Message {
+	var msgs []types.Message
+	for i := len(c.messages) - 1; i >= 0 && len(msgs) < n; i-- {
+		msg := c.messages[i].msg
+		if _, ok := msg.(types.ClearChat); ok {
+			continue
+		}
+		if strings.EqualFold(msg.GetLogin(), login) {
+			msgs = append([]types.Message{msg}, msgs...)
+		}
+	}
+	return msgs
+}
+
+func (i *inspector) view(width int, height int, keys KeyMap, box lipgloss.Style) string {
+	var b strings.Builder
+	var name string
+	var badges map[string]int
+	recent := i.channel.userMessages(i.login, inspectorMessages)
+	if len(recent) > 0 {
+		last := recent[len(recent)-1]
+		name = ansi.Strip(last.GetName())
+		badges = last.GetBadges()
+	}
+
+	b.WriteString(labelStyle.Render(fmt.Sprintf("@%s", i.login)))
+	if name != "" && !strings.EqualFold(name, i.login) {
+		b.WriteString(fmt.Sprintf(" (%s)", name))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("badges:"), formatBadges(badges)))
+
+	switch {
+	case i.err != nil:
+		b.WriteString(fmt.Sprintf("%s %v\n", labelStyle.Render("account:"), i.err))
+	case i.info == nil:
+		b.WriteString(fmt.Sprintf("%s loading…\n", labelStyle.Render("account:")))
+	default:
+		b.WriteString(fmt.Sprintf("%s created %s (%s ago)\n", labelStyle.Render("account:"), i.info.CreatedAt.Format(dateLayout), age(i.info.CreatedAt)))
+		switch {
+		case !i.info.FollowKnown:
+			b.WriteString(fmt.Sprintf("%s unknown\n", labelStyle.Render("follow:")))
+		case i.info.FollowedAt.IsZero():
+			b.WriteString(fmt.Sprintf("%s not following\n", labelStyle.Render("follow:")))
+		default:
+			b.WriteString(fmt.Sprintf("%s since %s (%s ago)\n", labelStyle.Render("follow:"), i.info.FollowedAt.Format(dateLayout), age(i.info.FollowedAt)))
+		}
+	}
+
+	b.WriteString(labelStyle.Render("this session:"))
+	bans := i.channel.bans[i.login]
+	if len(bans) == 0 {
+		b.WriteString(" no timeouts or bans\n")
+	} else {
+		b.WriteString("\n")
+		for _, ban := range bans {
+			what := "banned"
+			if ban.Duration > 0 {
+				what = fmt.Sprintf("timed out for %s", duration.Format(ban.Duration))
+			}
+			b.WriteString(fmt.Sprintf("  %s %s\n", ban.GetTime().Format(timeLayout), what))
+		}
+	}
+
+	b.WriteString(labelStyle.Render("recent messages:"))
+	if len(recent) == 0 {
+		b.WriteString(" none\n")
+	} else {
+		b.WriteString("\n")
+		for _, msg := range recent {
+			b.WriteString(fmt.Sprintf("  %s %s\n", msg.GetTime().Format(timeLayout), msg.GetText()))
+		}
+	}
+
+	b.WriteString(replyStyle.Render(fmt.Sprintf("%s close · %s timeout · %s ban · %s unban",
+		keys.Cancel.Help().Key, keys.Timeout.Help().Key, keys.Ban.Help().Key, keys.Unban.Help().Key)))
+
+	innerWidth := width - box.GetHorizontalFrameSize()
+	var lines []string
+	for _, l := range strings.Split(b.String(), "\n") {
+		lines = append(lines, ansi.Truncate(l, innerWidth, "…"))
+	}
+	if maxLines := height - box.GetVerticalFrameSize(); maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box.Render(strings.Join(lines, "\n")))
+}
+
+func formatBadges(badges map[string]int) string {
+	if len(badges) == 0 {
+		return "none"
+	}
+
+	var names []string
+	for badge, version := range badges {
+		if version > 1 {
+			badge = fmt.Sprintf("%s/%d", badge, version)
+		}
+		names = append(names, badge)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// age formats how long ago t was in the largest sensible unit
+func age(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d >= 365*24*time.Hour:
+		return fmt.Sprintf("%dy", int(d.Hours()/(365*24)))
+	case d >= 30*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(d.Hours()/(30*24)))
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	default:
+		return duration.Format(d.Truncate(time.Minute))
+	}
+}
@@ -0,0 +1,206 @@
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// KeyMap binds keys to actions. Chat keys apply while typing, selection keys while a message is selected.
+type KeyMap struct {
+	Quit        key.Binding
+	Cancel      key.Binding
+	Send        key.Binding
+	Clear       key.Binding
+	NextTab     key.Binding
+	PrevTab     key.Binding
+	NextMention key.Binding
+	NextPane    key.Binding
+	Select      key.Binding
+	Help        key.Binding
+	HistoryPrev key.Binding
+	HistoryNext key.Binding
+	ScrollUp    key.Binding
+	ScrollDown  key.Binding
+	Links       key.Binding
+
+	Up      key.Binding
+	Down    key.Binding
+	Reply   key.Binding
+	Timeout key.Binding
+	Ban     key.Binding
+	Unban   key.Binding
+	Delete  key.Binding
+	Inspect key.Binding
+	Jump    key.Binding
+	Copy    key.Binding
+}
+
+const (
+	DefaultKeys = "default"
+	ViKeys      = "vi"
+	EmacsKeys   = "emacs"
+)
+
+var (
+	presetKeys = map[string]map[string][]string{
+		DefaultKeys: {},
+		ViKeys: {
+			"nextTab":     {"tab", "alt+l"},
+			"prevTab":     {"shift+tab", "alt+h"},
+			"select":      {"ctrl+s", "alt+k"},
+			"nextMention": {"ctrl+g", "alt+n"},
+			"up":          {"k", "up", "ctrl+y"},
+			"down":        {"j", "down", "ctrl+e"},
+		},
+		EmacsKeys: {
+			"cancel":      {"ctrl+g", "esc"},
+			"nextTab":     {"tab", "alt+n"},
+			"prevTab":     {"shift+tab", "alt+p"},
+			"nextMention": {"alt+m"},
+			"nextPane":    {"ctrl+o"},
+			"links":       {"ctrl+x"},
+			"select":      {"ctrl+s", "ctrl+r"},
+			"up":          {"ctrl+p", "up"},
+			"down":        {"ctrl+n", "down"},
+		},
+	}
+)
+
+// DefaultKeyMap returns the keys of the default preset
+func DefaultKeyMap() KeyMap {
+	k, _ := NewKeyMap(DefaultKeys, nil)
+	return k
+}
+
+// NewKeyMap returns the keys of preset, with the actions in overrides bound to other keys
+func NewKeyMap(preset string, overrides map[string][]string) (KeyMap, error) {
+	if preset == "" {
+		preset = DefaultKeys
+	}
+	keys, ok := presetKeys[preset]
+	if !ok {
+		return KeyMap{}, fmt.Errorf("unknown key preset %q, expected %q, %q or %q", preset, DefaultKeys, ViKeys, EmacsKeys)
+	}
+
+	k := KeyMap{
+		Quit:        binding("quit", "ctrl+c"),
+		Cancel:      binding("cancel reply or selection", "esc"),
+		Send:        binding("send", "enter"),
+		Clear:       binding("clear input", "ctrl+u"),
+		NextTab:     binding("next tab", "tab"),
+		PrevTab:     binding("previous tab", "shift+tab"),
+		NextMention: binding("next unread mention", "ctrl+g"),
+		NextPane:    binding("next pane", "ctrl+w"),
+		Select:      binding("select a message", "ctrl+s"),
+		Help:        binding("help", "?", "f1"),
+		HistoryPrev: binding("previous sent message", "up"),
+		HistoryNext: binding("next sent message", "down"),
+		ScrollUp:    binding("scroll up", "pgup"),
+		ScrollDown:  binding("scroll down", "pgdown"),
+		Links:       binding("copy a recent link", "ctrl+o"),
+
+		Up:      binding("previous message", "up", "k"),
+		Down:    binding("next message", "down", "j"),
+		Reply:   binding("reply", "enter", "r"),
+		Timeout: binding("timeout", "t"),
+		Ban:     binding("ban", "b"),
+		Unban:   binding("unban", "u"),
+		Delete:  binding("delete", "d"),
+		Inspect: binding("inspect user", "i"),
+		Jump:    binding("go to mention", "g"),
+		Copy:    binding("copy", "c"),
+	}
+
+	actions := k.actions()
+	for _, set := range []map[string][]string{keys, overrides} {
+		for name, bound := range set {
+			b, ok := actions[name]
+			if !ok {
+				return KeyMap{}, fmt.Errorf("unknown key action %q, expected one of %s", name, strings.Join(actionNames(actions), ", "))
+			}
+			if len(bound) == 0 {
+				return KeyMap{}, fmt.Errorf("no keys for key action %q", name)
+			}
+			*b = binding(b.Help().Desc, bound...)
+		}
+	}
+	return k, nil
+}
+
+func binding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(keys, "/"), desc))
+}
+
+// actions returns the bindings of k by their name in the config
+func (k *KeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":        &k.Quit,
+		"cancel":      &k.Cancel,
+		"send":        &k.Send,
+		"clear":       &k.Clear,
+		"nextTab":     &k.NextTab,
+		"prevTab":     &k.PrevTab,
+		"nextMention": &k.NextMention,
+		"nextPane":    &k.NextPane,
+		"select":      &k.Select,
+		"help":        &k.Help,
+		"historyPrev": &k.HistoryPrev,
+		"historyNext": &k.HistoryNext,
+		"scrollUp":    &k.ScrollUp,
+		"scrollDown":  &k.ScrollDown,
+		"links":       &k.Links,
+		"up":          &k.Up,
+		"down":        &k.Down,
+		"reply":       &k.Reply,
+		"timeout":     &k.Timeout,
+		"ban":         &k.Ban,
+		"unban":       &k.Unban,
+		"delete":      &k.Delete,
+		"inspect":     &k.Inspect,
+		"jump":        &k.Jump,
+		"copy":        &k.Copy,
+	}
+}
+
+func actionNames(actions map[string]*key.Binding) []string {
+	var names []string
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Help, k.Quit}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Send, k.Clear, k.NextTab, k.PrevTab, k.NextMention, k.NextPane, k.HistoryPrev, k.HistoryNext, k.ScrollUp, k.ScrollDown, k.Links, k.Select, k.Cancel, k.Help, k.Quit},
+		{k.Up, k.Down, k.Reply, k.Jump, k.Inspect, k.Copy, k.Timeout, k.Ban, k.Unban, k.Delete},
+	}
+}
+
+// WithKeyMap replaces the default keys
+func WithKeyMap(keys KeyMap) ModelOption {
+	return func(m *Model) {
+		m.keys = keys
+	}
+}
+
+// helpView lists the bindings of the keymap in a box
+func (m *Model) helpView(width int, height int) string {
+	h := help.New()
+	h.ShowAll = true
+	h.Width = width - m.styles.box.GetHorizontalFrameSize()
+
+	text := fmt.Sprintf("%s\n\n%s", labelStyle.Render("chat · selected message"), h.View(m.keys))
+	box := m.styles.box.Render(text)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,126 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		Name      string
+		preset    string
+		overrides map[string][]string
+		action    string
+		want      []string
+		wantErr   string
+	}{
+		{Name: "default", action: "prevTab", want: []string{"shift+tab"}},
+		{Name: "default help", action: "help", want: []string{"?", "f1"}},
+		{Name: "vi", preset: ViKeys, action: "prevTab", want: []string{"shift+tab", "alt+h"}},
+		{Name: "vi select", preset: ViKeys, action: "select", want: []string{"ctrl+s", "alt+k"}},
+		{Name: "vi keeps other defaults", preset: ViKeys, action: "nextPane", want: []string{"ctrl+w"}},
+		{Name: "emacs", preset: EmacsKeys, action: "cancel", want: []string{"ctrl+g", "esc"}},
+		{Name: "override replaces the preset", preset: ViKeys, overrides: map[string][]string{"nextTab": {"ctrl+right"}}, action: "nextTab", want: []string{"ctrl+right"}},
+		{Name: "unknown preset", preset: "nano", wantErr: `unknown key preset "nano", expected "default", "vi" or "emacs"`},
+		{Name: "no keys", overrides: map[string][]string{"quit": {}}, wantErr: `no keys for key action "quit"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			k, err := NewKeyMap(test.preset, test.overrides)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Errorf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := k.actions()[test.action].Keys(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %s keys %q, got %q", test.action, test.want, got)
+			}
+		})
+	}
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, err := NewKeyMap("", map[string][]string{"fly": {"f"}})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestPresetKeys(t *testing.T) {
+	// keys the input edits with
+	editing := make(map[string]bool)
+	v := reflect.ValueOf(textinput.DefaultKeyMap)
+	for i := 0; i < v.NumField(); i++ {
+		for _, k := range v.Field(i).Interface().(key.Binding).Keys() {
+			editing[k] = true
+		}
+	}
+	// keys that do the same in the input and ttchat
+	shared := map[string]bool{"tab": true, "up": true, "down": true}
+	// actions on a selected message, when the input doesn't get keys
+	selection := map[string]bool{"up": true, "down": true}
+
+	for preset, actions := range presetKeys {
+		for action, keys := range actions {
+			if selection[action] {
+				continue
+			}
+			for _, k := range keys {
+				if editing[k] && !shared[k] {
+					t.Errorf("expected %s %s not to take %s from the input", preset, action, k)
+				}
+			}
+		}
+	}
+
+}
+
+func TestHelp(t *testing.T) {
+	t.Run("question mark", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"})
+
+		press(m, "?")
+		if !m.showHelp {
+			t.Fatalf("expected help")
+		}
+		press(m, "x")
+		if m.showHelp || m.textInput.Value() != "" {
+			t.Errorf("expected help to close without typing, got %t and %q", m.showHelp, m.textInput.Value())
+		}
+	})
+
+	t.Run("question mark while typing is text", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"})
+
+		press(m, "why", "?")
+
+		if m.showHelp {
+			t.Errorf("expected no help")
+		}
+		if m.textInput.Value() != "why?" {
+			t.Errorf("expected input why?, got %q", m.textInput.Value())
+		}
+	})
+
+	t.Run("f1 while typing", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"})
+		press(m, "hi")
+
+		press(m, "f1")
+		if !m.showHelp {
+			t.Fatalf("expected help")
+		}
+		press(m, "x")
+		if m.showHelp || m.textInput.Value() != "hi" {
+			t.Errorf("expected help to close and keep the input, got %t and %q", m.showHelp, m.textInput.Value())
+		}
+	})
+}
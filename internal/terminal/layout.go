@@ -0,0 +1,221 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Split is how panes are tiled when showing several channels at once
+type Split string
+
+const (
+	Single  Split = ""
+	Columns Split = "columns" // panes side by side
+	Rows    Split = "rows"    // panes stacked
+
+	minPanes = 2
+	maxPanes = 4
+)
+
+// WithLayout starts with n panes tiled by split
+func WithLayout(split Split, n int) ModelOption {
+	return func(m *Model) {
+		m.split(split, n)
+	}
+}
+
+// split tiles n channels, starting with the active one, or shows only the active channel for Single
+func (m *Model) split(split Split, n int) {
+	if split == Single || len(m.channels) < minPanes {
+		m.layout = Single
+		m.panes = []int{m.activeChannel}
+		m.focus = 0
+		m.resizePanes()
+		return
+	}
+
+	if n < minPanes {
+		n = minPanes
+	}
+	if n > maxPanes {
+		n = maxPanes
+	}
+	if n > len(m.channels) {
+		n = len(m.channels)
+	}
+
+	m.layout = split
+	m.panes = nil
+	for i := 0; i < n; i++ {
+		m.panes = append(m.panes, (m.activeChannel+i)%len(m.channels))
+	}
+	m.focus = 0
+	m.resizePanes()
+	for _, i := range m.panes {
+		m.channels[i].markRead()
+	}
+	m.setTabs(m.channels[m.activeChannel].name)
+}
+
+// splitCommand runs "/split [columns|rows] [panes]" and "/unsplit"
+func (m *Model) splitCommand(v string) bool {
+	fields := strings.Fields(v)
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "/unsplit":
+		m.split(Single, 0)
+		return true
+	case "/split":
+	default:
+		return false
+	}
+
+	split, n := Columns, minPanes
+	for _, f := range fields[1:] {
+		switch f {
+		case string(Columns), string(Rows):
+			split = Split(f)
+		default:
+			parsed, err := strconv.Atoi(f)
+			if err != nil || parsed < minPanes || parsed > maxPanes {
+				m.status = fmt.Sprintf("usage: /split [columns|rows] [%d-%d]", minPanes, maxPanes)
+				return true
+			}
+			n = parsed
+		}
+	}
+	m.split(split, n)
+	return true
+}
+
+// focusNext moves input to the next pane
+func (m *Model) focusNext() {
+	if len(m.panes) < minPanes {
+		return
+	}
+	m.focusPane((m.focus + 1) % len(m.panes))
+}
+
+// focusPane moves input to pane p
+func (m *Model) focusPane(p int) {
+	m.cancelReply()
+	m.focus = p
+	m.activeChannel = m.panes[m.focus]
+	m.channels[m.activeChannel].markRead()
+	m.setTabs(m.channels[m.activeChannel].name)
+	m.updateSuggestions()
+}
+
+// show puts channel i in the focused pane, swapping panes if it's already shown in another
+func (m *Model) show(i int) {
+	for p, c := range m.panes {
+		if c == i && p != m.focus {
+			m.panes[p] = m.panes[m.focus]
+		}
+	}
+	old := m.panes[m.focus]
+	m.panes[m.focus] = i
+	if old != i && m.layout != Single {
+		m.resizePanes()
+	}
+}
+
+// shown reports whether ch is in a pane
+func (m *Model) shown(ch *Channel) bool {
+	for _, i := range m.panes {
+		if m.channels[i] == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// resizePanes gives each pane its share of the window and every hidden channel the whole window
+func (m *Model) resizePanes() {
+	if m.width == 0 {
+		return
+	}
+	height := m.height - linesOffset
+	for _, ch := range m.channels {
+		if !m.shown(ch) || m.layout == Single {
+			ch.resize(height, m.width)
+		}
+	}
+	if m.layout == Single {
+		return
+	}
+
+	n := len(m.panes)
+	for p, i := range m.panes {
+		w, h := m.paneSize(p, n, height)
+		m.channels[i].resize(h, w)
+	}
+}
+
+// paneSize is the width and height of pane p of n, leaving room for titles and separators
+func (m *Model) paneSize(p int, n int, height int) (int, int) {
+	switch m.layout {
+	case Columns:
+		w := (m.width - (n - 1)) / n
+		if p == n-1 {
+			w = m.width - (n-1)*(w+1)
+		}
+		return w, height - 1
+	default:
+		h := (height - n) / n
+		if p == n-1 {
+			h = height - n - (n-1)*h
+		}
+		return m.width, h
+	}
+}
+
+// panesView renders every pane with its channel name above it
+func (m *Model) panesView() string {
+	var views []string
+	for p, i := range m.panes {
+		ch := m.channels[i]
+		title := m.styles.nonActive.UnsetBorderStyle().Render(ch.name)
+		if p == m.focus {
+			title = m.styles.active.UnsetBorderStyle().Render(ch.name)
+		}
+
+		var b strings.Builder
+		b.WriteString(pad(title, ch.width))
+		b.WriteString("\n")
+		for _, l := range strings.Split(strings.TrimSuffix(m.channelView(ch, p == m.focus), "\n"), "\n") {
+			b.WriteString(pad(l, ch.width))
+			b.WriteString("\n")
+		}
+		views = append(views, strings.TrimSuffix(b.String(), "\n"))
+	}
+
+	if m.layout == Columns {
+		height := lipgloss.Height(views[0])
+		sep := strings.TrimSuffix(strings.Repeat(m.styles.separator+"\n", height), "\n")
+		var row []string
+		for i, v := range views {
+			if i > 0 {
+				row = append(row, sep)
+			}
+			row = append(row, v)
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Top, row...) + "\n"
+	}
+	return strings.Join(views, "\n") + "\n"
+}
+
+// pad truncates or fills s with spaces to exactly width cells
+func pad(s string, width int) string {
+	s = ansi.Truncate(s, width, "")
+	if w := ansi.StringWidth(s); w < width {
+		s += strings.Repeat(" ", width-w)
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		Name       string
+		channels   []string
+		command    string
+		layout     Split
+		panes      []int
+		sizes      [][2]int // width and height of each pane
+		wantStatus string
+	}{
+		{
+			Name:     "columns",
+			channels: []string{"a", "b", "c"},
+			command:  "/split",
+			layout:   Columns,
+			panes:    []int{0, 1},
+			sizes:    [][2]int{{39, 18}, {40, 18}},
+		},
+		{
+			Name:     "rows",
+			channels: []string{"a", "b", "c"},
+			command:  "/split rows 3",
+			layout:   Rows,
+			panes:    []int{0, 1, 2},
+			sizes:    [][2]int{{80, 5}, {80, 5}, {80, 6}},
+		},
+		{
+			Name:     "more panes than channels",
+			channels: []string{"a", "b"},
+			command:  "/split columns 4",
+			layout:   Columns,
+			panes:    []int{0, 1},
+			sizes:    [][2]int{{39, 18}, {40, 18}},
+		},
+		{
+			Name:     "one channel",
+			channels: []string{"a"},
+			command:  "/split",
+			layout:   Single,
+			panes:    []int{0},
+			sizes:    [][2]int{{80, 19}},
+		},
+		{
+			Name:       "too many panes",
+			channels:   []string{"a", "b"},
+			command:    "/split 5",
+			layout:     Single,
+			panes:      []int{0},
+			sizes:      [][2]int{{80, 19}},
+			wantStatus: "usage: /split [columns|rows] [2-4]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m, ircs := newTestModel(test.channels)
+
+			press(m, test.command, "enter")
+
+			if m.layout != test.layout {
+				t.Errorf("expected layout %q, got %q", test.layout, m.layout)
+			}
+			if !reflect.DeepEqual(m.panes, test.panes) {
+				t.Errorf("expected panes %v, got %v", test.panes, m.panes)
+			}
+			for p, i := range m.panes {
+				ch := m.channels[i]
+				if p < len(test.sizes) && (ch.width != test.sizes[p][0] || ch.height != test.sizes[p][1]) {
+					t.Errorf("expected pane %d to be %dx%d, got %dx%d", p, test.sizes[p][0], test.sizes[p][1], ch.width, ch.height)
+				}
+			}
+			if m.status != test.wantStatus {
+				t.Errorf("expected status %q, got %q", test.wantStatus, m.status)
+			}
+			if len(ircs["a"].published) != 0 {
+				t.Errorf("expected nothing published, got %v", ircs["a"].published)
+			}
+		})
+	}
+
+	t.Run("unsplit", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a", "b", "c"}, WithLayout(Columns, 3))
+
+		press(m, "ctrl+w", "/unsplit", "enter")
+
+		if m.layout != Single || !reflect.DeepEqual(m.panes, []int{1}) {
+			t.Errorf("expected only b to be shown, got layout %q and panes %v", m.layout, m.panes)
+		}
+		for _, ch := range m.channels {
+			if ch.width != 80 || ch.height != 19 {
+				t.Errorf("expected %s to fill the window, got %dx%d", ch.name, ch.width, ch.height)
+			}
+		}
+	})
+}
+
+func TestPanes(t *testing.T) {
+	t.Run("focus next", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a", "b", "c"}, WithLayout(Columns, 2))
+		m.Update(chat("b", "1", "foo", "hi"))
+		if m.channels[1].unread != 0 {
+			t.Errorf("expected no unread messages in a shown pane, got %d", m.channels[1].unread)
+		}
+
+		for _, want := range []int{1, 0} {
+			press(m, "ctrl+w")
+			if m.focus != want || m.activeChannel != m.panes[want] {
+				t.Errorf("expected pane %d to be focused, got %d with channel %d", want, m.focus, m.activeChannel)
+			}
+		}
+	})
+
+	t.Run("switching tabs swaps shown channels", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a", "b", "c"}, WithLayout(Columns, 2))
+
+		press(m, "tab")
+		if !reflect.DeepEqual(m.panes, []int{1, 0}) {
+			t.Errorf("expected panes [1 0], got %v", m.panes)
+		}
+
+		press(m, "tab")
+		if !reflect.DeepEqual(m.panes, []int{2, 0}) {
+			t.Errorf("expected panes [2 0], got %v", m.panes)
+		}
+		if b := m.channels[1]; b.width != 80 || b.height != 19 {
+			t.Errorf("expected the hidden channel to fill the window, got %dx%d", b.width, b.height)
+		}
+	})
+
+	t.Run("resize", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a", "b"}, WithLayout(Rows, 2))
+
+		m.Update(tea.WindowSizeMsg{Width: 100, Height: 45})
+
+		for p, want := range []int{19, 19} {
+			if ch := m.channels[m.panes[p]]; ch.width != 100 || ch.height != want {
+				t.Errorf("expected pane %d to be 100x%d, got %dx%d", p, want, ch.width, ch.height)
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package terminal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/atye/ttchat/internal/types"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Clipboard copies text to the system clipboard
+type Clipboard interface {
+	Copy(string) string // returns the escape sequence for the terminal
+}
+
+// linkPicker lists the links of recent messages to copy one
+type linkPicker struct {
+	links    []link
+	selected int
+}
+
+type link struct {
+	url  string
+	name string // who sent it
+}
+
+const (
+	maxLinks = 20
+)
+
+var (
+	// control characters are left out so a link can't end an escape sequence it's put in
+	linkPattern = regexp.MustCompile(`https?://[^\s<>"\x00-\x1f\x7f]+`)
+)
+
+// WithClipboard copies selected messages, names and links
+func WithClipboard(clipboard Clipboard) ModelOption {
+	return func(m *Model) {
+		m.clipboard = clipboard
+	}
+}
+
+// findLinks returns the URLs in text
+func findLinks(text string) []string {
+	var links []string
+	for _, loc := range linkIndexes(text) {
+		links = append(links, text[loc[0]:loc[1]])
+	}
+	return links
+}
+
+// linkIndexes returns the start and end of each URL in text, leaving out punctuation that ends a sentence
+func linkIndexes(text string) [][]int {
+	var indexes [][]int
+	for _, loc := range linkPattern.FindAllStringIndex(text, -1) {
+		url := text[loc[0]:loc[1]]
+		for len(url) > 0 {
+			last := url[len(url)-1]
+			if strings.IndexByte(".,;:!?'", last) >= 0 ||
+				(last == ')' && strings.Count(url, "(") < strings.Count(url, ")")) ||
+				(last == ']' && strings.Count(url, "[") < strings.Count(url, "]")) {
+				url = url[:len(url)-1]
+				continue
+			}
+			break
+		}
+		if len(url) > len("https://") {
+			indexes = append(indexes, []int{loc[0], loc[0] + len(url)})
+		}
+	}
+	return indexes
+}
+
+// hyperlink makes the URLs in text clickable with OSC 8
+func hyperlink(text string) string {
+	indexes := linkIndexes(text)
+	if len(indexes) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range indexes {
+		url := text[loc[0]:loc[1]]
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(ansi.SetHyperlink(url))
+		b.WriteString(url)
+		b.WriteString(ansi.ResetHyperlink())
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// copy writes the clipboard's escape sequence with the View
+func (m *Model) copy(what string, text string) tea.Cmd {
+	if m.clipboard == nil {
+		m.status = "no clipboard"
+		return nil
+	}
+	m.status = fmt.Sprintf("copied %s", what)
+	return m.emit(m.clipboard.Copy(text))
+}
+
+// copySelected asks whether to copy the text, name or a link of msg
+func (m *Model) copySelected(msg types.Message) {
+	text := ansi.Strip(msg.GetText())
+	name := ansi.Strip(msg.GetName())
+	links := findLinks(text)
+
+	p := &prompt{
+		text: "copy: [m] message · [n] name",
+		actions: map[string]func() tea.Cmd{
+			"m": func() tea.Cmd {
+				return m.copy("message", text)
+			},
+			"n": func() tea.Cmd {
+				return m.copy("name", name)
+			},
+		},
+	}
+	if len(links) > 0 {
+		p.text += " · [l] link"
+		p.actions["l"] = func() tea.Cmd {
+			if len(links) == 1 {
+				return m.copy("link", links[0])
+			}
+			picker := &linkPicker{}
+			for _, url := range links {
+				picker.links = append(picker.links, link{url: url, name: name})
+			}
+			m.picking = picker
+			return nil
+		}
+	}
+	m.prompt = p
+}
+
+// pickLink lists the links of the active channel's recent messages, newest first
+func (m *Model) pickLink() {
+	ch := m.channels[m.activeChannel]
+	picker := &linkPicker{}
+	seen := make(map[string]bool)
+	for i := len(ch.messages) - 1; i >= 0 && len(picker.links) < maxLinks; i-- {
+		msg := ch.messages[i].msg
+		for _, url := range findLinks(ansi.Strip(msg.GetText())) {
+			if seen[url] || len(picker.links) == maxLinks {
+				continue
+			}
+			seen[url] = true
+			picker.links = append(picker.links, link{url: url, name: ansi.Strip(msg.GetName())})
+		}
+	}
+	if len(picker.links) == 0 {
+		m.status = fmt.Sprintf("no links in %s", ch.name)
+		return
+	}
+	m.picking = picker
+}
+
+func (m *Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.picking
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Cancel, m.keys.Links):
+		m.picking = nil
+	case key.Matches(msg, m.keys.Up):
+		p.selected = max(0, p.selected-1)
+	case key.Matches(msg, m.keys.Down):
+		p.selected = min(len(p.links)-1, p.selected+1)
+	case key.Matches(msg, m.keys.Send, m.keys.Copy):
+		m.picking = nil
+		return m, tea.Batch(m.copy("link", p.links[p.selected].url), listenForMessages(m))
+	}
+	return m, listenForMessages(m)
+}
+
+func (p *linkPicker) view(width int, height int, keys KeyMap, box lipgloss.Style) string {
+	var b strings.Builder
+	b.WriteString(labelStyle.Render("links"))
+	b.WriteString("\n")
+	for i, l := range p.links {
+		entry := fmt.Sprintf("%s %s", l.url, sourceStyle.Render(fmt.Sprintf("@%s", l.name)))
+		if i == p.selected {
+			entry = selected.Render(fmt.Sprintf("%s @%s", l.url, l.name))
+		}
+		b.WriteString(entry)
+		b.WriteString("\n")
+	}
+	b.WriteString(replyStyle.Render(fmt.Sprintf("%s/%s move · %s copy · %s close",
+		keys.Up.Help().Key, keys.Down.Help().Key, keys.Send.Help().Key, keys.Cancel.Help().Key)))
+
+	innerWidth := width - box.GetHorizontalFrameSize()
+	var lines []string
+	for _, l := range strings.Split(b.String(), "\n") {
+		lines = append(lines, ansi.Truncate(l, innerWidth, "…"))
+	}
+	if maxLines := height - box.GetVerticalFrameSize(); maxLines > 0 && len(lines) > maxLines {
+		// keep the selected link in view
+		start := min(max(0, p.selected+2-maxLines), len(lines)-maxLines)
+		lines = lines[start : start+maxLines]
+	}
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box.Render(strings.Join(lines, "\n")))
+}
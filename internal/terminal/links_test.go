@@ -0,0 +1,120 @@
+package terminal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mockClipboard struct{}
+
+func (mockClipboard) Copy(text string) string {
+	return fmt.Sprintf("\x1b]52;c;%s\a", text)
+}
+
+func TestFindLinks(t *testing.T) {
+	tests := []struct {
+		Name    string
+		text    string
+		want    []string
+		indexes [][]int
+	}{
+		{"none", "no links here", nil, nil},
+		{"http and https", "http://a.io and https://b.io/x?y=1", []string{"http://a.io", "https://b.io/x?y=1"}, [][]int{{0, 11}, {16, 34}}},
+		{"sentence", "see https://example.com.", []string{"https://example.com"}, [][]int{{4, 23}}},
+		{"parentheses", "(https://en.wikipedia.org/wiki/Go_(game))", []string{"https://en.wikipedia.org/wiki/Go_(game)"}, [][]int{{1, 40}}},
+		{"brackets", "[https://a.io/b]", []string{"https://a.io/b"}, [][]int{{1, 15}}},
+		{"quotes", `"https://a.io"`, []string{"https://a.io"}, [][]int{{1, 13}}},
+		{"scheme only", "https://...", nil, nil},
+		{"control characters", "https://a.io\x07b", []string{"https://a.io"}, [][]int{{0, 12}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := findLinks(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected links %q, got %q", test.want, got)
+			}
+			if got := linkIndexes(test.text); !reflect.DeepEqual(got, test.indexes) {
+				t.Errorf("expected indexes %v, got %v", test.indexes, got)
+			}
+		})
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	tests := []struct {
+		Name string
+		text string
+		want string
+	}{
+		{"none", "no links here", "no links here"},
+		{"link", "see https://example.com ok", "see \x1b]8;;https://example.com\ahttps://example.com\x1b]8;;\a ok"},
+		{"trailing punctuation", "(https://example.com/a_(b)).", "(\x1b]8;;https://example.com/a_(b)\ahttps://example.com/a_(b)\x1b]8;;\a)."},
+		{"styled", "\x1b[31mhttps://a.io\x1b[0m", "\x1b[31m\x1b]8;;https://a.io\ahttps://a.io\x1b]8;;\a\x1b[0m"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := hyperlink(test.text); got != test.want {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	tests := []struct {
+		Name       string
+		keys       []string
+		want       string
+		wantStatus string
+	}{
+		{"message", []string{"c", "m"}, "\x1b]52;c;hi https://a.io\a", "copied message"},
+		{"name", []string{"c", "n"}, "\x1b]52;c;foo\a", "copied name"},
+		{"link", []string{"c", "l"}, "\x1b]52;c;https://a.io\a", "copied link"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m, _ := newTestModel([]string{"a"}, WithClipboard(mockClipboard{}))
+			m.Update(chat("a", "1", "foo", "hi https://a.io"))
+
+			press(m, "ctrl+s")
+			press(m, test.keys...)
+
+			if !strings.HasPrefix(m.View(), test.want) {
+				t.Errorf("expected the view to start with %q", test.want)
+			}
+			if m.status != test.wantStatus {
+				t.Errorf("expected status %q, got %q", test.wantStatus, m.status)
+			}
+		})
+	}
+
+	t.Run("picked link", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a"}, WithClipboard(mockClipboard{}))
+		m.Update(chat("a", "1", "foo", "https://a.io"))
+		m.Update(chat("a", "2", "bar", "https://b.io"))
+
+		press(m, "ctrl+o", "down", "enter")
+
+		if !strings.HasPrefix(m.View(), "\x1b]52;c;https://a.io\a") {
+			t.Errorf("expected the older link to be copied")
+		}
+		if m.picking != nil {
+			t.Errorf("expected the picker to close")
+		}
+	})
+
+	t.Run("no clipboard", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a"})
+		m.Update(chat("a", "1", "foo", "hi"))
+
+		press(m, "ctrl+s", "c", "m")
+
+		if m.status != "no clipboard" || len(m.escapes) != 0 {
+			t.Errorf("expected nothing copied, got status %q", m.status)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/atye/ttchat/internal/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	MentionsChannel = "Mentions"
+)
+
+// Notifier alerts the user to a mention they can't see
+type Notifier interface {
+	Notify(string, string, string) string // channel, user name, message; returns escape sequences for the terminal
+}
+
+// mention is a highlighted message copied to the Mentions tab from the tab it was shown in
+type mention struct {
+	types.Message
+	source string
+	id     int // the message's id in its source tab
+}
+
+func (m mention) GetSource() string {
+	return m.source
+}
+
+// mentionsIRC backs the Mentions tab, which only receives messages from the model
+type mentionsIRC struct{}
+
+func (mentionsIRC) IncomingMessages() <-chan types.Message { return nil }
+
+func (mentionsIRC) Publish(string) {}
+
+func (mentionsIRC) Reply(types.Message, string) {}
+
+// WithMentions adds a Mentions tab collecting messages that mention the user
+// or match a highlight rule in any tab
+func WithMentions(lineSpacing int, opts ...ChannelOption) ModelOption {
+	return func(m *Model) {
+		m.mentions = NewChannel(mentionsIRC{}, MentionsChannel, lineSpacing, opts...)
+		m.channels = append(m.channels, m.mentions)
+	}
+}
+
+// WithNotifier notifies about mentions in tabs that aren't shown, or in any tab while the terminal is unfocused
+func WithNotifier(notifier Notifier) ModelOption {
+	return func(m *Model) {
+		m.notifier = notifier
+	}
+}
+
+// notify alerts the user to msg, shown in ch, unless they are looking at it
+func (m *Model) notify(ch *Channel, msg types.Message) tea.Cmd {
+	if m.notifier == nil || ch == m.mentions || !msg.IsHighlighted() {
+		return nil
+	}
+	if m.shown(ch) && !m.blurred {
+		return nil
+	}
+	return m.emit(m.notifier.Notify(ch.name, msg.GetName(), msg.GetText()))
+}
+
+// addMention copies msg, shown in ch with id, to the Mentions tab
+func (m *Model) addMention(ch *Channel, id int, msg types.Message) {
+	if m.mentions == nil || ch == m.mentions || !msg.IsHighlighted() {
+		return
+	}
+	m.mentions.update(mention{Message: msg, source: ch.name, id: id})
+	if m.channels[m.activeChannel] != m.mentions {
+		m.mentions.unread++
+	}
+}
+
+// jumpToContext switches to the tab a mention was shown in and selects it there
+func (m *Model) jumpToContext(selected types.Message) {
+	men, ok := selected.(mention)
+	if !ok {
+		return
+	}
+	for i, ch := range m.channels {
+		if ch.name != men.source {
+			continue
+		}
+		m.channels[m.activeChannel].clearSelection()
+		m.setActive(i)
+		if ch.visible(men.id) {
+			ch.selected = men.id
+			return
+		}
+		m.selecting = false
+		m.status = fmt.Sprintf("the message is no longer shown in %s", ch.name)
+		return
+	}
+}
+
+// replyChannel is the tab a reply to msg is sent in, the source tab for mentions
+func (m *Model) replyChannel(msg types.Message) *Channel {
+	if men, ok := msg.(mention); ok {
+		for _, ch := range m.channels {
+			if ch.name == men.source {
+				return ch
+			}
+		}
+	}
+	return m.channels[m.activeChannel]
+}
@@ -0,0 +1,186 @@
+package terminal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atye/ttchat/internal/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func highlighted(channel string, id string, name string, text string) types.PrivateMessage {
+	msg := chat(channel, id, name, text)
+	msg.Highlighted = true
+	return msg
+}
+
+func TestMentions(t *testing.T) {
+	t.Run("routing", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess", "other"}, WithMentions(0))
+		m.Update(chat("chess", "a", "foo", "hi"))
+		m.Update(highlighted("chess", "b", "foo", "hi @me"))
+		m.Update(highlighted("other", "c", "bar", "@me hello"))
+
+		got := m.mentions.messages
+		if len(got) != 2 {
+			t.Fatalf("expected 2 mentions, got %d", len(got))
+		}
+		for i, want := range []struct{ id, source string }{{"b", "chess"}, {"c", "other"}} {
+			if got[i].msg.GetID() != want.id || got[i].msg.GetSource() != want.source {
+				t.Errorf("expected mention %s from %s, got %s from %s", want.id, want.source, got[i].msg.GetID(), got[i].msg.GetSource())
+			}
+		}
+		if m.mentions.unread != 2 {
+			t.Errorf("expected 2 unread mentions, got %d", m.mentions.unread)
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"}, WithMentions(0))
+
+		press(m, "tab", "hello", "enter")
+
+		if want := fmt.Sprintf("%s is read-only, select a message to reply", MentionsChannel); m.status != want {
+			t.Errorf("expected status %q, got %q", want, m.status)
+		}
+		if len(ircs["chess"].published) != 0 {
+			t.Errorf("expected nothing published, got %v", ircs["chess"].published)
+		}
+	})
+
+	t.Run("reply in the source channel", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess", "other"}, WithMentions(0))
+		m.Update(highlighted("other", "c", "bar", "@me hello"))
+
+		press(m, "shift+tab", "ctrl+s", "enter", "hi", "enter")
+
+		want := reply{parentID: "c", text: "hi"}
+		if got := ircs["other"].replies; len(got) != 1 || got[0] != want {
+			t.Errorf("expected reply %v in other, got %v", want, got)
+		}
+	})
+}
+
+func TestJumpToContext(t *testing.T) {
+	t.Run("selects the message in its channel", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess", "other"}, WithMentions(0))
+		m.Update(chat("chess", "a", "foo", "hi"))
+		m.Update(highlighted("chess", "b", "foo", "hi @me"))
+		m.Update(chat("chess", "c", "foo", "bye"))
+
+		press(m, "shift+tab", "ctrl+s", "g")
+
+		if ch := m.channels[m.activeChannel]; ch.name != "chess" {
+			t.Fatalf("expected chess to be active, got %s", ch.name)
+		}
+		if !m.selecting {
+			t.Errorf("expected to be selecting")
+		}
+		selected, ok := m.channels[0].selectedMessage()
+		if !ok || selected.GetID() != "b" {
+			t.Errorf("expected message b to be selected, got %v", selected)
+		}
+		if _, ok := m.mentions.selectedMessage(); ok {
+			t.Errorf("expected the selection in %s to be cleared", MentionsChannel)
+		}
+	})
+
+	t.Run("no longer shown", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"}, WithMentions(0))
+		m.Update(highlighted("chess", "b", "foo", "hi @me"))
+		for i := 0; i < 30; i++ {
+			m.Update(chat("chess", fmt.Sprint(i), "foo", "spam"))
+		}
+
+		press(m, "tab", "ctrl+s", "g")
+
+		if ch := m.channels[m.activeChannel]; ch.name != "chess" {
+			t.Fatalf("expected chess to be active, got %s", ch.name)
+		}
+		if m.selecting {
+			t.Errorf("expected selection to end")
+		}
+		if want := "the message is no longer shown in chess"; m.status != want {
+			t.Errorf("expected status %q, got %q", want, m.status)
+		}
+	})
+}
+
+type mockNotifier struct {
+	notified []string
+}
+
+func (n *mockNotifier) Notify(channel string, name string, text string) string {
+	n.notified = append(n.notified, fmt.Sprintf("%s %s: %s", channel, name, text))
+	return "\a"
+}
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		Name   string
+		events []tea.Msg // before the message
+		msg    types.Message
+		want   []string
+	}{
+		{
+			Name: "shown and focused",
+			msg:  highlighted("chess", "a", "foo", "hi @me"),
+		},
+		{
+			Name: "hidden tab",
+			msg:  highlighted("other", "a", "foo", "hi @me"),
+			want: []string{"other foo: hi @me"},
+		},
+		{
+			Name:   "blurred",
+			events: []tea.Msg{tea.BlurMsg{}},
+			msg:    highlighted("chess", "a", "foo", "hi @me"),
+			want:   []string{"chess foo: hi @me"},
+		},
+		{
+			Name:   "focused again",
+			events: []tea.Msg{tea.BlurMsg{}, tea.FocusMsg{}},
+			msg:    highlighted("chess", "a", "foo", "hi @me"),
+		},
+		{
+			Name: "not highlighted",
+			msg:  chat("other", "a", "foo", "hi"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			notifier := &mockNotifier{}
+			m, _ := newTestModel([]string{"chess", "other"}, WithNotifier(notifier))
+			for _, e := range test.events {
+				m.Update(e)
+			}
+
+			m.Update(test.msg)
+
+			if !reflect.DeepEqual(notifier.notified, test.want) {
+				t.Errorf("expected notifications %q, got %q", test.want, notifier.notified)
+			}
+			if got := strings.HasPrefix(m.View(), "\a"); got != (len(test.want) > 0) {
+				t.Errorf("expected the bell in the View %t, got %t", len(test.want) > 0, got)
+			}
+		})
+	}
+
+	t.Run("escape sequences leave the View", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess", "other"}, WithNotifier(&mockNotifier{}))
+		m.Update(highlighted("other", "a", "foo", "hi @me"))
+		m.Update(highlighted("other", "b", "foo", "hi again @me"))
+
+		m.Update(escapedMsg(1))
+		if !strings.HasPrefix(m.View(), "\a") || strings.HasPrefix(m.View(), "\a\a") {
+			t.Errorf("expected one bell left in the View, got %q", m.View())
+		}
+		m.Update(escapedMsg(2))
+		if strings.HasPrefix(m.View(), "\a") {
+			t.Errorf("expected no bell in the View, got %q", m.View())
+		}
+	})
+}
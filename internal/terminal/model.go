@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"log"
 	"strings"
-	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/atye/ttchat/internal/theme"
 	"github.com/atye/ttchat/internal/types"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 type Model struct {
@@ -20,11 +24,52 @@ type Model struct {
 	tabs          string
 	textInput     textinput.Model
 	mode          mode
+	selecting     bool
+	replyTo       types.Message
+	prompt        *prompt
+	presets       []time.Duration
+	inspecting    *inspector
+	lookup        UserLookup
+	ignoreList    IgnoreList
+	status        string
+	mentions      *Channel
+	notifier      Notifier
+	blurred       bool
+	layout        Split
+	panes         []int // indexes of the channels shown
+	focus         int   // the pane receiving input, showing the active channel
+	width         int
+	height        int
+	keys          KeyMap
+	showHelp      bool
+	history       History
+	recalling     int    // the history entry in the input, or defaultRecalling
+	draft         string // what was typed before recalling history
+	tabEnds       []int  // the x after each tab
+	bounds        []bounds
+	clipboard     Clipboard
+	picking       *linkPicker
+	readOnly      bool
+	escapes       []string // escape sequences written with the View
+	escaped       int      // the number of escape sequences removed from the View
+	pastes        int      // split pastes started
+	pasting       int      // the split paste being sent, or 0
+	styles        styles
+}
+
+type ModelOption func(*Model)
+
+// prompt waits for one of its keys, such as choosing a timeout duration
+type prompt struct {
+	text    string
+	actions map[string]func() tea.Cmd
 }
 
 type line struct {
-	value  string
-	author string
+	value     string
+	msgID     int
+	nameStart int // the cells of the user's name, for clicking it
+	nameEnd   int
 }
 
 type mode int
@@ -38,23 +83,67 @@ var (
 	linesOffset = 5
 )
 
-func NewModel(log *log.Logger, channels ...*Channel) *Model {
+const (
+	minCompletionLength = 2
+	defaultPrompt       = "> "
+)
+
+var (
+	DefaultTimeoutPresets = []time.Duration{time.Minute, 10 * time.Minute, time.Hour, 24 * time.Hour}
+)
+
+// WithTimeoutPresets sets the durations offered when timing out a selected user
+func WithTimeoutPresets(presets []time.Duration) ModelOption {
+	return func(m *Model) {
+		if len(presets) > 0 {
+			m.presets = presets
+		}
+	}
+}
+
+// WithReadOnly disables the input, for watching chat without logging in
+func WithReadOnly() ModelOption {
+	return func(m *Model) {
+		m.readOnly = true
+		m.textInput.Placeholder = "Read-only, logged in anonymously"
+		m.textInput.Blur()
+	}
+}
+
+func NewModel(log *log.Logger, channels []*Channel, opts ...ModelOption) *Model {
 	ti := textinput.NewModel()
 	ti.Placeholder = "Send a message"
+	ti.PlaceholderStyle = placeholderStyle
+	ti.ShowSuggestions = true
 	ti.Focus()
 
-	return &Model{
+	m := &Model{
 		channels:  channels,
 		textInput: ti,
 		mode:      Initialize,
 		log:       log,
+		presets:   DefaultTimeoutPresets,
+		panes:     []int{0},
+		keys:      DefaultKeyMap(),
+		recalling: defaultRecalling,
+		styles:    defaultStyles(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	for _, ch := range m.channels {
+		ch.timestampStyle = m.styles.timestamp
 	}
+	return m
 }
 
 func (m *Model) Init() tea.Cmd {
 	incomingMsg := make(chan types.Message)
 	for _, ch := range m.channels {
 		ch := ch
+		if ch.incomingMsg == nil {
+			continue
+		}
 		go func() {
 			for {
 				msg := <-ch.incomingMsg
@@ -72,61 +161,133 @@ func (m *Model) Init() tea.Cmd {
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEscape:
-			return m, tea.Quit
-		case tea.KeyCtrlU:
-			m.textInput.SetValue("")
-			return m, listenForMessages(m)
-		case tea.KeyEnter:
-			if v := strings.TrimSpace(m.textInput.Value()); v != "" {
-				m.channels[m.activeChannel].irc.Publish(v)
-				m.textInput.SetValue("")
+		m.status = ""
+		if m.prompt != nil {
+			return m.updatePrompt(msg)
+		}
+		if m.inspecting != nil {
+			return m.updateInspector(msg)
+		}
+		if m.picking != nil {
+			return m.updatePicker(msg)
+		}
+		if m.selecting {
+			return m.updateSelection(msg)
+		}
+
+		if m.showHelp {
+			m.showHelp = false
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
 			}
 			return m, listenForMessages(m)
-		case tea.KeyTab:
-			if m.activeChannel+1 >= len(m.channels) {
-				m.activeChannel = 0
+		}
+		if m.readOnly && (msg.Paste || key.Matches(msg, m.keys.Send, m.keys.HistoryPrev, m.keys.HistoryNext)) {
+			m.status = readOnlyStatus
+			return m, listenForMessages(m)
+		}
+		if msg.Paste {
+			return m.paste(msg)
+		}
+		if msg.Type == tea.KeyRunes && m.textInput.Value() != "" {
+			// while typing, printable keys are text even when bound to an action
+			return m.updateInput(msg)
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Cancel):
+			if m.pasting != 0 {
+				m.stopPaste("stopped sending pasted messages")
+			} else if m.replyTo != nil {
+				m.cancelReply()
 			} else {
-				m.activeChannel++
+				m.status = fmt.Sprintf("%s to quit", m.keys.Quit.Help().Key)
+			}
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+		case key.Matches(msg, m.keys.Select):
+			m.selecting = true
+			m.channels[m.activeChannel].selectPrevious()
+		case key.Matches(msg, m.keys.NextPane):
+			m.focusNext()
+		case key.Matches(msg, m.keys.NextMention):
+			m.nextMention()
+		case key.Matches(msg, m.keys.Links):
+			m.pickLink()
+		case key.Matches(msg, m.keys.Clear):
+			m.textInput.SetValue("")
+			m.updateSuggestions()
+		case key.Matches(msg, m.keys.HistoryPrev), key.Matches(msg, m.keys.HistoryNext):
+			if m.textInput.CurrentSuggestion() != "" {
+				return m.updateInput(msg)
 			}
-			m.setTabs(m.channels[m.activeChannel].name)
-		case tea.KeyShiftTab:
-			if m.activeChannel-1 < 0 {
-				m.activeChannel = len(m.channels) - 1
+			if key.Matches(msg, m.keys.HistoryPrev) {
+				m.recall(-1)
 			} else {
-				m.activeChannel--
+				m.recall(1)
 			}
-			m.setTabs(m.channels[m.activeChannel].name)
+		case key.Matches(msg, m.keys.ScrollUp), key.Matches(msg, m.keys.ScrollDown):
+			ch := m.channels[m.activeChannel]
+			n := max(1, ch.height/2)
+			if key.Matches(msg, m.keys.ScrollDown) {
+				n = -n
+			}
+			ch.scrollBy(n)
+		case key.Matches(msg, m.keys.Send):
+			if v := strings.TrimSpace(m.textInput.Value()); v != "" {
+				if n := utf8.RuneCountInString(v); n > maxMessageLength {
+					m.status = fmt.Sprintf("the message is %d characters, Twitch allows %d", n, maxMessageLength)
+					return m, listenForMessages(m)
+				}
+				cmd, ok := m.send(v)
+				if !ok {
+					return m, listenForMessages(m)
+				}
+				m.textInput.SetValue("")
+				m.updateSuggestions()
+				return m, tea.Batch(cmd, listenForMessages(m))
+			}
+		case key.Matches(msg, m.keys.NextTab):
+			if msg.Type == tea.KeyTab && m.textInput.CurrentSuggestion() != "" {
+				return m.updateInput(msg)
+			}
+			m.cancelReply()
+			m.setActive((m.activeChannel + 1) % len(m.channels))
+		case key.Matches(msg, m.keys.PrevTab):
+			m.cancelReply()
+			m.setActive((m.activeChannel - 1 + len(m.channels)) % len(m.channels))
 		default:
-			var cmd tea.Cmd
-			m.textInput, cmd = m.textInput.Update(msg)
-			return m, cmd
+			return m.updateInput(msg)
 		}
+		return m, listenForMessages(m)
 	case tea.WindowSizeMsg:
-		var wg sync.WaitGroup
-		switch m.mode {
-		case Initialize:
-			for _, ch := range m.channels {
-				ch := ch
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					ch.initLines(msg.Height - linesOffset)
-				}()
-			}
-			wg.Wait()
-			m.mode = Run
-		case Run:
-			for _, ch := range m.channels {
-				ch := ch
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					ch.resize(msg.Height-linesOffset, msg.Width)
-				}()
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resizePanes()
+		m.mode = Run
+		return m, listenForMessages(m)
+	case tea.FocusMsg:
+		m.blurred = false
+		return m, listenForMessages(m)
+	case tea.BlurMsg:
+		m.blurred = true
+		return m, listenForMessages(m)
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	case pastedMsg:
+		return m.updatePasted(msg)
+	case ignoredMsg:
+		m.status = string(msg)
+		return m, listenForMessages(m)
+	case userInfoMsg:
+		if m.inspecting != nil && m.inspecting.login == msg.login {
+			if msg.err != nil {
+				m.inspecting.err = msg.err
+			} else {
+				m.inspecting.info = &msg.info
 			}
-			wg.Wait()
 		}
 		return m, listenForMessages(m)
 	case types.Message:
@@ -141,27 +302,126 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.log.Printf("no channel found for %s\n", msg.GetChannel())
 			return m, listenForMessages(m)
 		}
-		ch.update(msg)
-		return m, listenForMessages(m)
+		m.addMention(ch, ch.update(msg), msg)
+		cmd := m.notify(ch, msg)
+		if !m.shown(ch) {
+			ch.unread++
+			if msg.IsHighlighted() {
+				ch.unreadMentions++
+			}
+		}
+		m.setTabs(m.channels[m.activeChannel].name)
+		return m, tea.Batch(cmd, listenForMessages(m))
+	case escapedMsg:
+		m.updateEscaped(msg)
+		return m, nil
 
 	default:
 		return m, listenForMessages(m)
 	}
-	return m, listenForMessages(m)
 }
 
 func (m *Model) View() string {
+	m.recordBounds()
+
 	var b strings.Builder
+	for _, seq := range m.escapes {
+		b.WriteString(seq)
+	}
 	b.WriteString(fmt.Sprintf("%s\n", m.tabs))
-	for _, line := range m.channels[m.activeChannel].lines {
-		b.WriteString(line.value)
+	ch := m.channels[m.activeChannel]
+	if m.showHelp {
+		b.WriteString(fmt.Sprintf("%s\n", m.helpView(m.width, m.height-linesOffset)))
+	} else if m.layout == Single {
+		b.WriteString(m.channelView(ch, true))
+	} else {
+		b.WriteString(m.panesView())
 	}
 
+	counter := m.counter()
+	width := max(0, m.width-ansi.StringWidth(counter)-1)
+	var status string
+	if m.prompt != nil {
+		status = promptStyle.Render(ansi.Truncate(m.prompt.text, width, "…"))
+	} else if m.status != "" {
+		status = statusStyle.Render(ansi.Truncate(m.status, width, "…"))
+	}
+	b.WriteString(status)
+	if counter != "" {
+		b.WriteString(strings.Repeat(" ", max(1, m.width-ansi.StringWidth(status)-ansi.StringWidth(counter))))
+		b.WriteString(counter)
+	}
 	b.WriteString("\n")
 	b.WriteString(m.textInput.View())
 	return b.String()
 }
 
+// send acts on v, sent in the active tab, as a command, a whisper, a reply or a message.
+// It reports false, with the reason in the status, when v can't be sent.
+func (m *Model) send(v string) (tea.Cmd, bool) {
+	to, text, isWhisper := parseWhisper(v)
+	if isWhisper && m.whisperer() == nil {
+		m.status = "whispers are disabled"
+		return nil, false
+	}
+	save := m.remember(v)
+	var cmd tea.Cmd
+	if isWhisper {
+		m.whisperer().Whisper(to, text)
+	} else if login, ok := parseUser(v); ok {
+		cmd = m.inspect(login)
+	} else if m.splitCommand(v) {
+		m.cancelReply()
+	} else if ignoring, ok := m.ignore(v); ok {
+		cmd = ignoring
+		m.cancelReply()
+	} else if m.moderate(v) {
+		m.cancelReply()
+	} else {
+		m.publish(v)
+	}
+	return tea.Batch(save, cmd), true
+}
+
+// publish sends v as text to the active tab, or as a reply to the message being replied to.
+// It reports false, with the reason in the status, when the tab can't be sent to.
+func (m *Model) publish(v string) bool {
+	if m.replyTo != nil {
+		m.replyChannel(m.replyTo).irc.Reply(m.replyTo, v)
+		m.cancelReply()
+		return true
+	}
+	if m.channels[m.activeChannel] == m.mentions {
+		m.status = fmt.Sprintf("%s is read-only, select a message to reply", MentionsChannel)
+		return false
+	}
+	m.channels[m.activeChannel].irc.Publish(v)
+	return true
+}
+
+// channelView renders the lines of ch, or the user inspector over them when focused
+func (m *Model) channelView(ch *Channel, focused bool) string {
+	if focused && m.inspecting != nil {
+		return fmt.Sprintf("%s\n", m.inspecting.view(ch.width, ch.height, m.keys, m.styles.box))
+	}
+	if focused && m.picking != nil {
+		return fmt.Sprintf("%s\n", m.picking.view(ch.width, ch.height, m.keys, m.styles.box))
+	}
+
+	var b strings.Builder
+	for _, line := range ch.lines {
+		if line.msgID != 0 && line.msgID == ch.selected {
+			b.WriteString(fmt.Sprintf("%s\n", selected.Render(ansi.Strip(strings.TrimSuffix(line.value, "\n")))))
+			continue
+		}
+		b.WriteString(line.value)
+	}
+	if ch.scroll > 0 && ch.height > 0 {
+		b.WriteString(fmt.Sprintf("%s\n", scrollStyle.Render(fmt.Sprintf("↓ %d more lines", ch.scroll))))
+	}
+	return b.String()
+}
+
 var (
 	highlight = lipgloss.AdaptiveColor{Light: "#efeff1", Dark: "#6441A5"}
 
@@ -169,25 +429,235 @@ var (
 		Bottom: "─",
 	}
 
-	active    = lipgloss.NewStyle().Foreground(lipgloss.Color("#6441A5")).Border(border).BorderForeground(highlight)
-	nonActive = lipgloss.NewStyle().Border(border)
+	selected    = lipgloss.NewStyle().Reverse(true)
+	promptStyle = lipgloss.NewStyle().Bold(true)
+	statusStyle = lipgloss.NewStyle().Faint(true)
+
+	unreadStyle = lipgloss.NewStyle().Faint(true)
+
+	placeholderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 )
 
+// styles are the themed parts of the View
+type styles struct {
+	active    lipgloss.Style // the active tab and the title of the focused pane
+	nonActive lipgloss.Style
+	mention   lipgloss.Style // unread mention counts on tabs
+	box       lipgloss.Style // the inspector, link picker and help
+	separator string         // between panes side by side
+	timestamp lipgloss.Style
+}
+
+func defaultStyles() styles {
+	return newStyles(highlight, lipgloss.NewStyle().Foreground(lipgloss.Color("#6441A5")), lipgloss.NewStyle(), timestampStyle)
+}
+
+func newStyles(accent lipgloss.TerminalColor, active lipgloss.Style, nonActive lipgloss.Style, timestamp lipgloss.Style) styles {
+	return styles{
+		active:    active.Border(border).BorderForeground(accent),
+		nonActive: nonActive.Border(border),
+		mention:   active.Bold(true),
+		box:       lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(accent).Padding(0, 1),
+		separator: lipgloss.NewStyle().Foreground(accent).Render("│"),
+		timestamp: timestamp,
+	}
+}
+
+// WithTheme styles tabs, panes, timestamps and the input with t
+func WithTheme(t theme.Theme) ModelOption {
+	return func(m *Model) {
+		var accent lipgloss.TerminalColor = highlight
+		if t.Accent != "" {
+			accent = lipgloss.Color(t.Accent)
+		}
+		m.styles = newStyles(accent, t.TabActive.Lipgloss(), t.TabInactive.Lipgloss(), t.Timestamp.Lipgloss())
+		m.textInput.PromptStyle = t.Prompt.Lipgloss()
+		m.textInput.TextStyle = t.Input.Lipgloss()
+		m.textInput.PlaceholderStyle = t.Placeholder.Lipgloss()
+	}
+}
+
+// nextMention switches to the next tab after the active one with unread mentions
+func (m *Model) nextMention() {
+	for j := 1; j < len(m.channels); j++ {
+		i := (m.activeChannel + j) % len(m.channels)
+		if m.channels[i].unreadMentions > 0 {
+			m.cancelReply()
+			m.setActive(i)
+			return
+		}
+	}
+	m.status = "no unread mentions"
+}
+
+// setActive switches to the tab at index i
+func (m *Model) setActive(i int) {
+	m.recalling = defaultRecalling
+	m.show(i)
+	m.activeChannel = i
+	m.channels[i].markRead()
+	m.setTabs(m.channels[m.activeChannel].name)
+	m.updateSuggestions()
+}
+
 func (m *Model) setTabs(activeTabName string) {
 	var tabs []string
 	for _, ch := range m.channels {
 		if ch.name == activeTabName {
-			tabs = append(tabs, active.Render(ch.name))
-		} else {
-			tabs = append(tabs, nonActive.Render(ch.name))
+			tabs = append(tabs, m.styles.active.Render(ch.name))
+			continue
+		}
+		name := ch.name
+		if ch.unread > 0 {
+			name = fmt.Sprintf("%s %s", name, unreadStyle.Render(fmt.Sprint(ch.unread)))
+		}
+		if ch.unreadMentions > 0 {
+			name = fmt.Sprintf("%s %s", name, m.styles.mention.Render(fmt.Sprintf("@%d", ch.unreadMentions)))
 		}
+		tabs = append(tabs, m.styles.nonActive.Render(name))
 	}
+	m.tabEnds = m.tabEnds[:0]
+	x := 0
+	for _, t := range tabs {
+		x += lipgloss.Width(t)
+		m.tabEnds = append(m.tabEnds, x)
+	}
+
 	row := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 	m.tabs = lipgloss.JoinHorizontal(lipgloss.Bottom, row)
 }
 
+// updateInput passes a key to the text input
+func (m *Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.readOnly {
+		m.status = readOnlyStatus
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	m.updateSuggestions()
+	return m, cmd
+}
+
+// updateSelection handles keys while moving the cursor over the active channel's messages
+func (m *Model) updateSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ch := m.channels[m.activeChannel]
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Up):
+		ch.selectPrevious()
+	case key.Matches(msg, m.keys.Down):
+		ch.selectNext()
+	case key.Matches(msg, m.keys.Reply):
+		if selected, ok := ch.selectedMessage(); ok {
+			m.startReply(selected)
+		}
+		m.selecting = false
+		ch.clearSelection()
+	case key.Matches(msg, m.keys.Cancel), key.Matches(msg, m.keys.Select):
+		m.selecting = false
+		ch.clearSelection()
+	case key.Matches(msg, m.keys.Timeout, m.keys.Ban, m.keys.Unban, m.keys.Delete):
+		if selected, ok := ch.selectedMessage(); ok {
+			m.moderateSelected(m.moderation(msg), selected)
+		}
+	case key.Matches(msg, m.keys.Copy):
+		if selected, ok := ch.selectedMessage(); ok {
+			m.selecting = false
+			ch.clearSelection()
+			m.copySelected(selected)
+		}
+	case key.Matches(msg, m.keys.Jump):
+		if selected, ok := ch.selectedMessage(); ok {
+			m.jumpToContext(selected)
+		}
+	case key.Matches(msg, m.keys.Inspect):
+		if selected, ok := ch.selectedMessage(); ok && selected.GetLogin() != "" {
+			m.selecting = false
+			ch.clearSelection()
+			return m, tea.Batch(m.inspect(selected.GetLogin()), listenForMessages(m))
+		}
+	}
+	return m, listenForMessages(m)
+}
+
+func (m *Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Cancel):
+		m.prompt = nil
+	default:
+		if action, ok := m.prompt.actions[msg.String()]; ok {
+			m.prompt = nil
+			return m, tea.Batch(action(), listenForMessages(m))
+		}
+	}
+	return m, listenForMessages(m)
+}
+
+// parseWhisper splits "/w user message" into its user and message
+func parseWhisper(v string) (string, string, bool) {
+	fields := strings.SplitN(v, " ", 3)
+	if len(fields) != 3 || (fields[0] != "/w" && fields[0] != "/whisper") {
+		return "", "", false
+	}
+	text := strings.TrimSpace(fields[2])
+	if fields[1] == "" || text == "" {
+		return "", "", false
+	}
+	return fields[1], text, true
+}
+
+// parseUser returns the user of "/user name"
+func parseUser(v string) (string, bool) {
+	fields := strings.Fields(v)
+	if len(fields) != 2 || fields[0] != "/user" {
+		return "", false
+	}
+	return fields[1], true
+}
+
+// whisperer returns the channel that sends whispers, or nil when whispers are disabled
+func (m *Model) whisperer() Whisperer {
+	for _, ch := range m.channels {
+		if w, ok := ch.irc.(Whisperer); ok {
+			return w
+		}
+	}
+	return nil
+}
+
+func (m *Model) cancelReply() {
+	m.replyTo = nil
+	m.textInput.Prompt = defaultPrompt
+}
+
+// updateSuggestions offers completions for the word being typed at the end of the input
+func (m *Model) updateSuggestions() {
+	value := m.textInput.Value()
+	i := strings.LastIndex(value, " ") + 1
+	word := value[i:]
+	if len(word) < minCompletionLength {
+		m.textInput.SetSuggestions(nil)
+		return
+	}
+
+	completions := m.channels[m.activeChannel].complete(word)
+	suggestions := make([]string, len(completions))
+	for j, c := range completions {
+		suggestions[j] = value[:i] + c
+	}
+	m.textInput.SetSuggestions(suggestions)
+}
+
 func listenForMessages(m *Model) tea.Cmd {
 	return func() tea.Msg {
 		return <-m.incomingMsg
 	}
 }
+
+func noAction() tea.Cmd {
+	return nil
+}
@@ -0,0 +1,547 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atye/ttchat/internal/theme"
+	"github.com/atye/ttchat/internal/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type mockIRC struct {
+	published []string
+	replies   []reply
+}
+
+type reply struct {
+	parentID string
+	text     string
+}
+
+func (i *mockIRC) IncomingMessages() <-chan types.Message { return nil }
+
+func (i *mockIRC) Publish(msg string) {
+	i.published = append(i.published, msg)
+}
+
+func (i *mockIRC) Reply(parent types.Message, msg string) {
+	i.replies = append(i.replies, reply{parentID: parent.GetID(), text: msg})
+}
+
+// newTestModel returns a model of channels backed by mock IRCs, sized to 80x24
+func newTestModel(channels []string, opts ...ModelOption) (*Model, map[string]*mockIRC) {
+	ircs := make(map[string]*mockIRC)
+	var chs []*Channel
+	for _, name := range channels {
+		irc := &mockIRC{}
+		ircs[name] = irc
+		chs = append(chs, NewChannel(irc, name, 0))
+	}
+
+	m := NewModel(log.New(io.Discard, "", 0), chs, opts...)
+	m.Init()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	return m, ircs
+}
+
+var testKeys = map[string]tea.KeyType{
+	"enter":     tea.KeyEnter,
+	"esc":       tea.KeyEscape,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"tab":       tea.KeyTab,
+	"shift+tab": tea.KeyShiftTab,
+	"pgup":      tea.KeyPgUp,
+	"pgdown":    tea.KeyPgDown,
+	"ctrl+g":    tea.KeyCtrlG,
+	"ctrl+o":    tea.KeyCtrlO,
+	"ctrl+s":    tea.KeyCtrlS,
+	"ctrl+w":    tea.KeyCtrlW,
+	"f1":        tea.KeyF1,
+}
+
+// press sends keys by name to m, anything that isn't a named key as typed text
+func press(m *Model, keys ...string) {
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if t, ok := testKeys[k]; ok {
+			msg = tea.KeyMsg{Type: t}
+		}
+		m.Update(msg)
+	}
+}
+
+func chat(channel string, id string, name string, text string) types.PrivateMessage {
+	return types.PrivateMessage{ID: id, Channel: channel, Login: name, Name: name, Text: text}
+}
+
+func TestSelection(t *testing.T) {
+	tests := []struct {
+		Name     string
+		keys     []string
+		selected string // ID of the selected message
+	}{
+		{"newest", []string{"ctrl+s"}, "b"},
+		{"previous", []string{"ctrl+s", "up"}, "a"},
+		{"stops at the oldest", []string{"ctrl+s", "up", "up", "up"}, "a"},
+		{"next", []string{"ctrl+s", "k", "j"}, "b"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m, _ := newTestModel([]string{"chess"})
+			m.Update(chat("chess", "a", "foo", "first"))
+			m.Update(chat("chess", "b", "bar", "second"))
+
+			press(m, test.keys...)
+
+			if !m.selecting {
+				t.Fatalf("expected to be selecting")
+			}
+			selected, ok := m.channels[0].selectedMessage()
+			if !ok || selected.GetID() != test.selected {
+				t.Errorf("expected message %s to be selected, got %v", test.selected, selected)
+			}
+		})
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"})
+		m.Update(chat("chess", "a", "foo", "first"))
+
+		press(m, "ctrl+s", "esc")
+
+		if m.selecting {
+			t.Errorf("expected selection to end")
+		}
+		if _, ok := m.channels[0].selectedMessage(); ok {
+			t.Errorf("expected no selected message")
+		}
+	})
+}
+
+func TestReply(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+		m.Update(chat("chess", "a", "foo", "first"))
+		m.Update(chat("chess", "b", "bar", "second"))
+
+		press(m, "ctrl+s", "up", "enter")
+
+		if m.selecting {
+			t.Errorf("expected selection to end")
+		}
+		if want := "↳ @foo > "; m.textInput.Prompt != want {
+			t.Errorf("expected prompt %q, got %q", want, m.textInput.Prompt)
+		}
+
+		press(m, "hello", "enter")
+
+		want := []reply{{parentID: "a", text: "hello"}}
+		if got := ircs["chess"].replies; len(got) != 1 || got[0] != want[0] {
+			t.Errorf("expected replies %v, got %v", want, got)
+		}
+		if len(ircs["chess"].published) != 0 {
+			t.Errorf("expected no published messages, got %v", ircs["chess"].published)
+		}
+		if m.replyTo != nil || m.textInput.Prompt != defaultPrompt {
+			t.Errorf("expected reply to end, got prompt %q", m.textInput.Prompt)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+		m.Update(chat("chess", "a", "foo", "first"))
+
+		press(m, "ctrl+s", "r", "esc", "hello", "enter")
+
+		if m.replyTo != nil || m.textInput.Prompt != defaultPrompt {
+			t.Errorf("expected reply to be cancelled, got prompt %q", m.textInput.Prompt)
+		}
+		if got := ircs["chess"].published; len(got) != 1 || got[0] != "hello" {
+			t.Errorf("expected hello to be published, got %v", got)
+		}
+	})
+
+	t.Run("message without an ID", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+		m.Update(chat("chess", "", "me", "my own message"))
+
+		press(m, "ctrl+s", "enter")
+
+		if m.replyTo != nil {
+			t.Errorf("expected no reply, got a reply to %v", m.replyTo)
+		}
+		if want := "this message can't be replied to"; m.status != want {
+			t.Errorf("expected status %q, got %q", want, m.status)
+		}
+
+		press(m, "hello", "enter")
+		if len(ircs["chess"].replies) != 0 {
+			t.Errorf("expected no replies, got %v", ircs["chess"].replies)
+		}
+	})
+}
+
+func TestReplyable(t *testing.T) {
+	tests := []struct {
+		Name string
+		msg  types.Message
+		want bool
+	}{
+		{"chat", chat("chess", "a", "foo", "hi"), true},
+		{"own message", chat("chess", "", "me", "hi"), false},
+		{"whisper", types.WhisperMessage{PrivateMessage: chat("Whispers", "", "foo", "hi"), Conversation: "foo"}, true},
+		{"whisper notice", types.WhisperMessage{PrivateMessage: chat("Whispers", "", "ttchat", "hi")}, false},
+		{"mention", mention{Message: chat("chess", "a", "foo", "hi @me"), source: "chess", id: 1}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := replyable(test.msg); got != test.want {
+				t.Errorf("expected %t, got %t", test.want, got)
+			}
+		})
+	}
+}
+
+type mockWhisperIRC struct {
+	mockIRC
+	whispers []string
+}
+
+func (i *mockWhisperIRC) Whisper(to string, msg string) {
+	i.whispers = append(i.whispers, fmt.Sprintf("%s: %s", to, msg))
+}
+
+func TestWhisper(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		whispers := &mockWhisperIRC{}
+		m, _ := newTestModel([]string{"chess"}, func(m *Model) {
+			m.channels = append(m.channels, NewChannel(whispers, "Whispers", 0))
+		})
+
+		press(m, "/w foo hi there", "enter")
+
+		if got := whispers.whispers; len(got) != 1 || got[0] != "foo: hi there" {
+			t.Errorf("expected whisper foo: hi there, got %v", got)
+		}
+		if m.textInput.Value() != "" {
+			t.Errorf("expected empty input, got %q", m.textInput.Value())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+
+		press(m, "/w foo hi", "enter")
+
+		if want := "whispers are disabled"; m.status != want {
+			t.Errorf("expected status %q, got %q", want, m.status)
+		}
+		if want := "/w foo hi"; m.textInput.Value() != want {
+			t.Errorf("expected input %q to be kept, got %q", want, m.textInput.Value())
+		}
+		if len(ircs["chess"].published) != 0 {
+			t.Errorf("expected nothing published, got %v", ircs["chess"].published)
+		}
+	})
+
+	t.Run("moderation command in the whispers tab", func(t *testing.T) {
+		whispers := &mockWhisperIRC{}
+		m, _ := newTestModel([]string{"chess"}, func(m *Model) {
+			m.channels = append(m.channels, NewChannel(whispers, "Whispers", 0))
+		})
+
+		press(m, "tab", "/ban foo", "enter")
+
+		if len(whispers.published) != 0 {
+			t.Errorf("expected nothing whispered, got %v", whispers.published)
+		}
+		if want := "/ban only works in chat channels"; m.status != want {
+			t.Errorf("expected status %q, got %q", want, m.status)
+		}
+	})
+}
+
+func TestUnread(t *testing.T) {
+	m, _ := newTestModel([]string{"chess", "other"})
+	m.Update(chat("chess", "a", "foo", "hi"))
+	m.Update(chat("other", "b", "foo", "hi"))
+	m.Update(highlighted("other", "c", "foo", "hi @me"))
+
+	if c := m.channels[0]; c.unread != 0 || c.unreadMentions != 0 {
+		t.Errorf("expected nothing unread in the active tab, got %d and %d mentions", c.unread, c.unreadMentions)
+	}
+	other := m.channels[1]
+	if other.unread != 2 || other.unreadMentions != 1 {
+		t.Errorf("expected 2 unread and 1 mention in other, got %d and %d", other.unread, other.unreadMentions)
+	}
+	if !strings.Contains(ansi.Strip(m.tabs), "other 2 @1") {
+		t.Errorf("expected the other tab to show its counts, got %q", ansi.Strip(m.tabs))
+	}
+
+	press(m, "tab")
+	if other.unread != 0 || other.unreadMentions != 0 {
+		t.Errorf("expected other to be read, got %d and %d mentions", other.unread, other.unreadMentions)
+	}
+	if strings.Contains(ansi.Strip(m.tabs), "@1") {
+		t.Errorf("expected no counts on the tabs, got %q", ansi.Strip(m.tabs))
+	}
+}
+
+func TestNextMention(t *testing.T) {
+	tests := []struct {
+		Name       string
+		mentions   []string // channels with an unread mention
+		want       string   // the active channel after the key
+		wantStatus string
+	}{
+		{"next", []string{"c"}, "c", ""},
+		{"wraps around", []string{"a"}, "a", ""},
+		{"nearest first", []string{"d", "c"}, "c", ""},
+		{"none", nil, "b", "no unread mentions"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m, _ := newTestModel([]string{"a", "b", "c", "d"})
+			press(m, "tab")
+			for _, c := range test.mentions {
+				m.Update(highlighted(c, "1", "foo", "hi @me"))
+			}
+
+			press(m, "ctrl+g")
+
+			if got := m.channels[m.activeChannel].name; got != test.want {
+				t.Errorf("expected %s to be active, got %s", test.want, got)
+			}
+			if m.status != test.wantStatus {
+				t.Errorf("expected status %q, got %q", test.wantStatus, m.status)
+			}
+		})
+	}
+}
+
+func TestWithTheme(t *testing.T) {
+	th := theme.Theme{
+		TabActive: theme.Style{Underline: true},
+		Timestamp: theme.Style{Italic: true},
+		Prompt:    theme.Style{Bold: true},
+	}
+	m, _ := newTestModel([]string{"chess"}, WithMentions(0), WithTheme(th))
+
+	for _, ch := range m.channels {
+		if got, want := ch.timestampStyle.Render("12:00"), th.Timestamp.Lipgloss().Render("12:00"); got != want {
+			t.Errorf("expected %s timestamps %q, got %q", ch.name, want, got)
+		}
+	}
+	if want := th.TabActive.Lipgloss().Render("chess"); !strings.Contains(m.tabs, want) {
+		t.Errorf("expected the active tab %q in %q", want, m.tabs)
+	}
+	if want := th.Prompt.Lipgloss().Render(defaultPrompt); !strings.HasPrefix(m.textInput.View(), want) {
+		t.Errorf("expected the prompt %q, got %q", want, m.textInput.View())
+	}
+
+	plain, _ := newTestModel([]string{"chess"})
+	if plain.tabs == m.tabs {
+		t.Errorf("expected the theme to change the tabs")
+	}
+}
+
+type mockLookup struct {
+	info   types.UserInfo
+	err    error
+	logins []string
+}
+
+func (l *mockLookup) LookupUser(channel string, login string) (types.UserInfo, error) {
+	l.logins = append(l.logins, fmt.Sprintf("%s/%s", channel, login))
+	return l.info, l.err
+}
+
+func TestInspector(t *testing.T) {
+	setup := func(opts ...ModelOption) *Model {
+		m, _ := newTestModel([]string{"a", "b"}, opts...)
+		m.Update(chat("a", "1", "foo", "first"))
+		m.Update(chat("a", "2", "bar", "not foo"))
+		m.Update(types.ClearChat{PrivateMessage: types.PrivateMessage{Channel: "a"}, Target: "foo", Duration: 10 * time.Minute})
+		// the name and badges are those of the latest message
+		second := chat("a", "3", "foo", "second")
+		second.Name = "Foo_Bar"
+		second.Badges = map[string]int{"subscriber": 12, "moderator": 1}
+		m.Update(second)
+		m.Update(chat("b", "4", "foo", "elsewhere"))
+		return m
+	}
+
+	t.Run("selected author", func(t *testing.T) {
+		m := setup()
+
+		press(m, "ctrl+s", "up", "up", "i")
+
+		if m.inspecting == nil || m.inspecting.login != "bar" {
+			t.Fatalf("expected bar to be inspected, got %+v", m.inspecting)
+		}
+		if m.selecting {
+			t.Errorf("expected the selection to end")
+		}
+	})
+
+	t.Run("view", func(t *testing.T) {
+		m := setup()
+
+		press(m, "ctrl+s", "i")
+		view := ansi.Strip(m.View())
+
+		for _, want := range []string{
+			"@foo (Foo_Bar)",
+			"badges: moderator, subscriber/12",
+			"account: loading…",
+			"timed out for 10m",
+			"first",
+			"second",
+		} {
+			if !strings.Contains(view, want) {
+				t.Errorf("expected %q in the inspector, got\n%s", want, view)
+			}
+		}
+		for _, other := range []string{"not foo", "elsewhere"} {
+			if strings.Contains(view, other) {
+				t.Errorf("expected only foo's messages of a, got %q", other)
+			}
+		}
+	})
+
+	t.Run("account age", func(t *testing.T) {
+		lookup := &mockLookup{info: types.UserInfo{
+			Login:       "foo",
+			CreatedAt:   time.Now().AddDate(-3, 0, -1),
+			FollowedAt:  time.Now().Add(-48 * time.Hour),
+			FollowKnown: true,
+		}}
+		m := setup(WithUserLookup(lookup))
+
+		m.Update(m.inspect("foo")())
+		view := ansi.Strip(m.View())
+
+		if !reflect.DeepEqual(lookup.logins, []string{"a/foo"}) {
+			t.Errorf("expected foo to be looked up in a, got %v", lookup.logins)
+		}
+		for _, want := range []string{
+			fmt.Sprintf("account: created %s (3y ago)", lookup.info.CreatedAt.Format(dateLayout)),
+			fmt.Sprintf("follow: since %s (2d ago)", lookup.info.FollowedAt.Format(dateLayout)),
+		} {
+			if !strings.Contains(view, want) {
+				t.Errorf("expected %q in the inspector, got\n%s", want, view)
+			}
+		}
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		m := setup(WithUserLookup(&mockLookup{err: fmt.Errorf("no such user")}))
+
+		m.Update(m.inspect("foo")())
+
+		if view := ansi.Strip(m.View()); !strings.Contains(view, "account: no such user") {
+			t.Errorf("expected the lookup error, got\n%s", view)
+		}
+	})
+
+	t.Run("stale lookup", func(t *testing.T) {
+		m := setup()
+
+		press(m, "/user foo", "enter")
+		m.Update(userInfoMsg{login: "bar", info: types.UserInfo{Login: "bar"}})
+
+		if m.inspecting == nil || m.inspecting.login != "foo" || m.inspecting.info != nil {
+			t.Errorf("expected foo's inspector to keep loading, got %+v", m.inspecting)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		for _, k := range []string{"esc", "i", "q"} {
+			m := setup()
+			press(m, "/user @Foo", "enter")
+			if m.inspecting == nil || m.inspecting.login != "foo" {
+				t.Fatalf("expected foo to be inspected, got %+v", m.inspecting)
+			}
+
+			press(m, k)
+
+			if m.inspecting != nil {
+				t.Errorf("expected %s to close the inspector", k)
+			}
+			if strings.Contains(ansi.Strip(m.View()), "recent messages:") {
+				t.Errorf("expected the channel to be shown after %s", k)
+			}
+		}
+	})
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		Name string
+		keys []string
+	}{
+		{"typing", []string{"hi"}},
+		{"send", []string{"enter"}},
+		{"history", []string{"up"}},
+		{"reply", []string{"ctrl+s", "enter"}},
+		{"reply with r", []string{"ctrl+s", "r"}},
+		{"timeout", []string{"ctrl+s", "t"}},
+		{"ban", []string{"ctrl+s", "b"}},
+		{"unban", []string{"ctrl+s", "u"}},
+		{"delete", []string{"ctrl+s", "d"}},
+		{"moderate from the inspector", []string{"ctrl+s", "i", "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			mod := &mockModIRC{enabled: true, moderator: true}
+			m, _ := newTestModel(nil, WithReadOnly(), func(m *Model) {
+				m.channels = append(m.channels, NewChannel(mod, "chess", 0))
+			})
+			m.Update(chat("chess", "1", "foo", "hi"))
+
+			press(m, test.keys...)
+			if m.status != readOnlyStatus {
+				t.Errorf("expected status %q, got %q", readOnlyStatus, m.status)
+			}
+
+			press(m, "y", "1", "esc")
+			m.Update(pasteKey("one\ntwo"))
+			if m.status != readOnlyStatus {
+				t.Errorf("expected paste to set status %q, got %q", readOnlyStatus, m.status)
+			}
+			if m.textInput.Value() != "" || m.replyTo != nil || m.prompt != nil {
+				t.Errorf("expected no input, reply or prompt, got %q, %v and %v", m.textInput.Value(), m.replyTo, m.prompt)
+			}
+			if len(mod.published) != 0 || len(mod.replies) != 0 || len(mod.calls) != 0 {
+				t.Errorf("expected nothing sent, got %q, %v and %q", mod.published, mod.replies, mod.calls)
+			}
+		})
+	}
+
+	t.Run("right click", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"}, WithReadOnly())
+		m.Update(chat("chess", "1", "foo", "hi"))
+		m.View()
+
+		m.Update(tea.MouseMsg{X: 1, Y: 21, Button: tea.MouseButtonRight, Action: tea.MouseActionPress})
+
+		if m.status != readOnlyStatus || m.replyTo != nil {
+			t.Errorf("expected status %q without a reply, got %q", readOnlyStatus, m.status)
+		}
+		if len(ircs["chess"].replies) != 0 {
+			t.Errorf("expected no replies, got %v", ircs["chess"].replies)
+		}
+	})
+}
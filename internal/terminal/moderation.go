@@ -0,0 +1,173 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atye/ttchat/internal/duration"
+	"github.com/atye/ttchat/internal/types"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Moderator is implemented by channels where the user can take moderator actions
+type Moderator interface {
+	ModerationEnabled() bool // moderation is configured for the channel
+	IsModerator() bool
+	Timeout(string, time.Duration, string) // user login, duration, reason
+	Ban(string, string)                    // user login, reason
+	Unban(string)                          // user login
+	Delete(string)                         // message ID
+}
+
+// moderate runs /timeout, /ban and /unban commands in the active channel
+func (m *Model) moderate(v string) bool {
+	fields := strings.Fields(v)
+	if len(fields) < 2 {
+		return false
+	}
+	switch fields[0] {
+	case "/timeout", "/ban", "/unban", "/untimeout":
+	default:
+		return false
+	}
+
+	mod, ok := m.moderator(fields[0])
+	if !ok {
+		// the command is never sent as a message, such as a whisper
+		return true
+	}
+
+	login := strings.TrimPrefix(fields[1], "@")
+	switch fields[0] {
+	case "/timeout":
+		d := m.presets[0]
+		reason := fields[2:]
+		if len(fields) > 2 {
+			if parsed, ok := parseTimeout(fields[2]); ok {
+				d = parsed
+				reason = fields[3:]
+			}
+		}
+		mod.Timeout(login, d, strings.Join(reason, " "))
+	case "/ban":
+		mod.Ban(login, strings.Join(fields[2:], " "))
+	case "/unban", "/untimeout":
+		mod.Unban(login)
+	}
+	return true
+}
+
+// parseTimeout accepts Go durations like 10m or a number of seconds like Twitch does
+func parseTimeout(s string) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d > 0
+}
+
+// moderateSelected takes the moderator action bound to key against the selected message
+func (m *Model) moderateSelected(key string, selected types.Message) {
+	m.moderateUser(key, selected.GetLogin(), selected.GetID(), ansi.Strip(selected.GetText()))
+	m.selecting = false
+	m.channels[m.activeChannel].clearSelection()
+}
+
+// moderation returns the moderateUser action of a key bound to timeout, ban, unban or delete
+func (m *Model) moderation(msg tea.KeyMsg) string {
+	switch {
+	case key.Matches(msg, m.keys.Timeout):
+		return "t"
+	case key.Matches(msg, m.keys.Ban):
+		return "b"
+	case key.Matches(msg, m.keys.Unban):
+		return "u"
+	case key.Matches(msg, m.keys.Delete):
+		return "d"
+	}
+	return ""
+}
+
+// moderateUser takes the moderator action bound to key against login, or their message with id
+func (m *Model) moderateUser(key string, login string, id string, text string) {
+	if login == "" {
+		return
+	}
+	mod, ok := m.moderator("moderation")
+	if !ok {
+		return
+	}
+	if !mod.IsModerator() {
+		m.status = "you are not a moderator of this channel"
+		return
+	}
+
+	switch key {
+	case "t":
+		p := &prompt{actions: make(map[string]func() tea.Cmd)}
+		var options []string
+		for i, d := range m.presets {
+			if i >= 9 {
+				break
+			}
+			d := d
+			k := strconv.Itoa(i + 1)
+			p.actions[k] = func() tea.Cmd {
+				mod.Timeout(login, d, "")
+				return nil
+			}
+			options = append(options, fmt.Sprintf("[%s] %s", k, duration.Format(d)))
+		}
+		p.text = fmt.Sprintf("timeout @%s: %s (esc to cancel)", login, strings.Join(options, " "))
+		m.prompt = p
+	case "b":
+		m.prompt = &prompt{
+			text: fmt.Sprintf("ban @%s? [y/n]", login),
+			actions: map[string]func() tea.Cmd{
+				"y": func() tea.Cmd {
+					mod.Ban(login, "")
+					return nil
+				},
+				"n": noAction,
+			},
+		}
+	case "u":
+		mod.Unban(login)
+	case "d":
+		if id != "" {
+			m.prompt = &prompt{
+				text: fmt.Sprintf("delete message from @%s: %q? [y/n]", login, text),
+				actions: map[string]func() tea.Cmd{
+					"y": func() tea.Cmd {
+						mod.Delete(id)
+						return nil
+					},
+					"n": noAction,
+				},
+			}
+		}
+	}
+}
+
+// moderator returns the active channel's Moderator, or sets the status explaining why what can't be used there
+func (m *Model) moderator(what string) (Moderator, bool) {
+	if m.readOnly {
+		m.status = readOnlyStatus
+		return nil, false
+	}
+	ch := m.channels[m.activeChannel]
+	mod, ok := ch.irc.(Moderator)
+	if !ok {
+		m.status = fmt.Sprintf("%s only works in chat channels", what)
+		return nil, false
+	}
+	if !mod.ModerationEnabled() {
+		m.status = "moderation is disabled, enable it with moderation.enabled"
+		return nil, false
+	}
+	return mod, true
+}
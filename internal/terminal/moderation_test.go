@@ -0,0 +1,120 @@
+package terminal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mockModIRC struct {
+	mockIRC
+	enabled   bool
+	moderator bool
+	calls     []string
+}
+
+func (i *mockModIRC) ModerationEnabled() bool { return i.enabled }
+
+func (i *mockModIRC) IsModerator() bool { return i.moderator }
+
+func (i *mockModIRC) Timeout(login string, d time.Duration, reason string) {
+	i.calls = append(i.calls, fmt.Sprintf("timeout %s %s %s", login, d, reason))
+}
+
+func (i *mockModIRC) Ban(login string, reason string) {
+	i.calls = append(i.calls, fmt.Sprintf("ban %s %s", login, reason))
+}
+
+func (i *mockModIRC) Unban(login string) {
+	i.calls = append(i.calls, fmt.Sprintf("unban %s", login))
+}
+
+func (i *mockModIRC) Delete(id string) {
+	i.calls = append(i.calls, fmt.Sprintf("delete %s", id))
+}
+
+func TestModerate(t *testing.T) {
+	tests := []struct {
+		Name       string
+		enabled    bool
+		moderator  bool
+		keys       []string
+		wantCalls  []string
+		wantStatus string
+	}{
+		{
+			Name:      "timeout command",
+			enabled:   true,
+			moderator: true,
+			keys:      []string{"/timeout @foo 10m spam", "enter"},
+			wantCalls: []string{"timeout foo 10m0s spam"},
+		},
+		{
+			Name:      "timeout in seconds",
+			enabled:   true,
+			moderator: true,
+			keys:      []string{"/timeout foo 30", "enter"},
+			wantCalls: []string{"timeout foo 30s "},
+		},
+		{
+			Name:      "ban command",
+			enabled:   true,
+			moderator: true,
+			keys:      []string{"/ban foo spam bot", "enter"},
+			wantCalls: []string{"ban foo spam bot"},
+		},
+		{
+			Name:      "ban key",
+			enabled:   true,
+			moderator: true,
+			keys:      []string{"ctrl+s", "b", "y"},
+			wantCalls: []string{"ban foo "},
+		},
+		{
+			Name:      "delete key",
+			enabled:   true,
+			moderator: true,
+			keys:      []string{"ctrl+s", "d", "y"},
+			wantCalls: []string{"delete a"},
+		},
+		{
+			Name:       "disabled command",
+			keys:       []string{"/ban foo", "enter"},
+			wantStatus: "moderation is disabled, enable it with moderation.enabled",
+		},
+		{
+			Name:       "disabled key",
+			keys:       []string{"ctrl+s", "b"},
+			wantStatus: "moderation is disabled, enable it with moderation.enabled",
+		},
+		{
+			Name:       "not a moderator",
+			enabled:    true,
+			keys:       []string{"ctrl+s", "t"},
+			wantStatus: "you are not a moderator of this channel",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			irc := &mockModIRC{enabled: test.enabled, moderator: test.moderator}
+			m, _ := newTestModel(nil, func(m *Model) {
+				m.channels = append(m.channels, NewChannel(irc, "chess", 0))
+			})
+			m.Update(chat("chess", "a", "foo", "spam"))
+
+			press(m, test.keys...)
+
+			if !reflect.DeepEqual(irc.calls, test.wantCalls) {
+				t.Errorf("expected calls %q, got %q", test.wantCalls, irc.calls)
+			}
+			if m.status != test.wantStatus {
+				t.Errorf("expected status %q, got %q", test.wantStatus, m.status)
+			}
+			if len(irc.published) != 0 {
+				t.Errorf("expected nothing published, got %v", irc.published)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/atye/ttchat/internal/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	wheelLines = 3
+
+	readOnlyStatus = "read-only, log in to chat"
+)
+
+var (
+	scrollStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// bounds is where a pane's lines were drawn in the last View
+type bounds struct {
+	pane   int
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+// recordBounds remembers where the panes of the View being rendered are on screen
+func (m *Model) recordBounds() {
+	m.bounds = m.bounds[:0]
+	y := lipgloss.Height(m.tabs)
+	x := 0
+	for p, i := range m.panes {
+		ch := m.channels[i]
+		switch m.layout {
+		case Single:
+			m.bounds = append(m.bounds, bounds{pane: p, x: 0, y: y, width: ch.width, height: len(ch.lines)})
+		case Columns:
+			m.bounds = append(m.bounds, bounds{pane: p, x: x, y: y + 1, width: ch.width, height: len(ch.lines)})
+			x += ch.width + 1
+		case Rows:
+			m.bounds = append(m.bounds, bounds{pane: p, x: 0, y: y + 1, width: ch.width, height: len(ch.lines)})
+			y += ch.height + 1
+		}
+	}
+}
+
+// updateMouse switches tabs, scrolls and acts on messages and names that are clicked
+func (m *Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.prompt != nil || m.inspecting != nil || m.picking != nil || m.showHelp {
+		return m, listenForMessages(m)
+	}
+
+	if msg.Y < lipgloss.Height(m.tabs) {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			for i, end := range m.tabEnds {
+				if msg.X < end {
+					m.cancelReply()
+					m.setActive(i)
+					break
+				}
+			}
+		}
+		return m, listenForMessages(m)
+	}
+
+	b, ok := m.boundsAt(msg.X, msg.Y)
+	if !ok {
+		return m, listenForMessages(m)
+	}
+	ch := m.channels[m.panes[b.pane]]
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		ch.scrollBy(wheelLines)
+	case tea.MouseButtonWheelDown:
+		ch.scrollBy(-wheelLines)
+	case tea.MouseButtonLeft, tea.MouseButtonRight:
+		if msg.Action != tea.MouseActionPress || m.selecting {
+			break
+		}
+		l := ch.lines[msg.Y-b.y]
+		clicked, ok := ch.message(l.msgID)
+		if !ok {
+			break
+		}
+		if b.pane != m.focus {
+			m.focusPane(b.pane)
+		}
+
+		col := msg.X - b.x
+		if msg.Button == tea.MouseButtonLeft && col >= l.nameStart && col < l.nameEnd && clicked.GetLogin() != "" {
+			return m, tea.Batch(m.inspect(clicked.GetLogin()), listenForMessages(m))
+		}
+		if msg.Button == tea.MouseButtonRight {
+			m.startReply(clicked)
+		}
+	}
+	return m, listenForMessages(m)
+}
+
+func (m *Model) boundsAt(x int, y int) (bounds, bool) {
+	for _, b := range m.bounds {
+		if x >= b.x && x < b.x+b.width && y >= b.y && y < b.y+b.height {
+			return b, true
+		}
+	}
+	return bounds{}, false
+}
+
+func (m *Model) startReply(msg types.Message) {
+	if m.readOnly {
+		m.status = readOnlyStatus
+		return
+	}
+	if !replyable(msg) {
+		m.status = "this message can't be replied to"
+		return
+	}
+	m.replyTo = msg
+	m.textInput.Prompt = fmt.Sprintf("↳ @%s %s", ansi.Strip(msg.GetName()), defaultPrompt)
+}
+
+// replyable reports whether msg can be replied to. Chat replies need the ID Twitch gave the parent,
+// which the user's own echoed messages and ttchat's notices don't have. Whispers reply by conversation.
+func replyable(msg types.Message) bool {
+	if men, ok := msg.(mention); ok {
+		msg = men.Message
+	}
+	if w, ok := msg.(types.WhisperMessage); ok {
+		return w.Conversation != ""
+	}
+	return msg.GetID() != ""
+}
@@ -0,0 +1,224 @@
+package terminal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func click(m *Model, x int, y int, button tea.MouseButton) {
+	m.Update(tea.MouseMsg{X: x, Y: y, Button: button, Action: tea.MouseActionPress})
+}
+
+func TestRecordBounds(t *testing.T) {
+	tests := []struct {
+		Name   string
+		opts   []ModelOption
+		scroll int // lines the first pane is scrolled up
+		want   []bounds
+	}{
+		{"single", nil, 0, []bounds{{pane: 0, x: 0, y: 3, width: 80, height: 19}}},
+		{"single scrolled", nil, 3, []bounds{{pane: 0, x: 0, y: 3, width: 80, height: 18}}},
+		{"columns", []ModelOption{WithLayout(Columns, 2)}, 0, []bounds{
+			{pane: 0, x: 0, y: 4, width: 39, height: 18},
+			{pane: 1, x: 40, y: 4, width: 40, height: 18},
+		}},
+		{"rows", []ModelOption{WithLayout(Rows, 2)}, 0, []bounds{
+			{pane: 0, x: 0, y: 4, width: 80, height: 8},
+			{pane: 1, x: 0, y: 13, width: 80, height: 9},
+		}},
+		{"rows scrolled", []ModelOption{WithLayout(Rows, 2)}, 3, []bounds{
+			{pane: 0, x: 0, y: 4, width: 80, height: 7},
+			{pane: 1, x: 0, y: 13, width: 80, height: 9},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m, _ := newTestModel([]string{"a", "b"}, test.opts...)
+			for i := 0; i < 30; i++ {
+				m.Update(chat("a", fmt.Sprint(i), "foo", "hi"))
+			}
+			m.channels[0].scrollBy(test.scroll)
+
+			m.recordBounds()
+
+			if !reflect.DeepEqual(m.bounds, test.want) {
+				t.Errorf("expected bounds %+v, got %+v", test.want, m.bounds)
+			}
+		})
+	}
+}
+
+func TestBoundsAt(t *testing.T) {
+	m, _ := newTestModel([]string{"a", "b"}, WithLayout(Columns, 2))
+	m.recordBounds()
+
+	tests := []struct {
+		Name string
+		x, y int
+		want int // the pane, or -1 for none
+	}{
+		{"first pane", 0, 4, 0},
+		{"first pane's last cell", 38, 21, 0},
+		{"separator", 39, 10, -1},
+		{"second pane", 40, 10, 1},
+		{"title row", 10, 3, -1},
+		{"below the panes", 10, 22, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			b, ok := m.boundsAt(test.x, test.y)
+			got := -1
+			if ok {
+				got = b.pane
+			}
+			if got != test.want {
+				t.Errorf("expected pane %d, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMouse(t *testing.T) {
+	t.Run("tab click", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a", "b", "c"})
+		m.View()
+
+		click(m, m.tabEnds[1], 0, tea.MouseButtonLeft)
+		if m.activeChannel != 2 {
+			t.Errorf("expected tab 2 to be active, got %d", m.activeChannel)
+		}
+		click(m, 0, 1, tea.MouseButtonLeft)
+		if m.activeChannel != 0 {
+			t.Errorf("expected tab 0 to be active, got %d", m.activeChannel)
+		}
+		click(m, m.tabEnds[2]+5, 0, tea.MouseButtonLeft)
+		if m.activeChannel != 0 {
+			t.Errorf("expected a click past the tabs to do nothing, got tab %d", m.activeChannel)
+		}
+	})
+
+	t.Run("click in another pane focuses it", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a", "b"}, WithLayout(Columns, 2))
+		m.Update(chat("b", "1", "foo", "hi"))
+		m.View()
+
+		click(m, 45, 21, tea.MouseButtonRight)
+
+		if m.focus != 1 || m.activeChannel != 1 {
+			t.Errorf("expected pane 1 to be focused, got %d", m.focus)
+		}
+		if m.replyTo == nil || m.replyTo.GetID() != "1" {
+			t.Errorf("expected a reply to message 1, got %v", m.replyTo)
+		}
+	})
+
+	t.Run("wheel", func(t *testing.T) {
+		m, _ := newTestModel([]string{"a"})
+		for i := 0; i < 30; i++ {
+			m.Update(chat("a", fmt.Sprint(i), "foo", "hi"))
+		}
+		m.View()
+
+		m.Update(tea.MouseMsg{X: 10, Y: 10, Button: tea.MouseButtonWheelUp})
+		if m.channels[0].scroll != wheelLines {
+			t.Errorf("expected to scroll %d lines, got %d", wheelLines, m.channels[0].scroll)
+		}
+		m.Update(tea.MouseMsg{X: 10, Y: 10, Button: tea.MouseButtonWheelDown})
+		if m.channels[0].scroll != 0 {
+			t.Errorf("expected to scroll back, got %d", m.channels[0].scroll)
+		}
+	})
+}
+
+func TestScroll(t *testing.T) {
+	m, _ := newTestModel([]string{"a"})
+	for i := 0; i < 30; i++ {
+		m.Update(chat("a", fmt.Sprint(i), "foo", fmt.Sprintf("message %d", i)))
+	}
+	rows := strings.Count(m.View(), "\n")
+	ch := m.channels[0]
+
+	press(m, "pgup")
+	if ch.scroll != 9 {
+		t.Fatalf("expected to scroll 9 lines, got %d", ch.scroll)
+	}
+	view := m.View()
+	if got := strings.Count(view, "\n"); got != rows {
+		t.Errorf("expected %d rows while scrolled, got %d", rows, got)
+	}
+	if !strings.Contains(view, "message 20\n↓ 9 more lines") {
+		t.Errorf("expected the marker below the last shown message, got %q", view)
+	}
+
+	shown := append([]line(nil), ch.lines...)
+	m.Update(chat("a", "new", "foo", "newest"))
+	if !reflect.DeepEqual(ch.lines, shown) {
+		t.Errorf("expected the shown lines to stay while scrolled")
+	}
+	if ch.scroll != 10 {
+		t.Errorf("expected to be scrolled 10 lines, got %d", ch.scroll)
+	}
+
+	press(m, "pgdown", "pgdown")
+	if ch.scroll != 0 || strings.Contains(m.View(), "more lines") {
+		t.Errorf("expected to be back at the newest without a marker, got scroll %d", ch.scroll)
+	}
+	if !strings.Contains(m.View(), "newest") {
+		t.Errorf("expected the newest message to be shown")
+	}
+}
+
+func TestScrollBy(t *testing.T) {
+	tests := []struct {
+		Name       string
+		height     int
+		n          int
+		wantScroll int
+		wantLines  int
+	}{
+		{"no rows", 0, 5, 0, 0},
+		{"one row", 1, 5, 0, 1},
+		{"two rows", 2, 5, 3, 1},
+		{"clamped to the oldest", 3, 9, 2, 2},
+		{"a few lines", 3, 2, 2, 2},
+		{"back to the newest", 3, -9, 0, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := NewChannel(&mockIRC{}, "chess", 0)
+			c.resize(test.height, 80)
+			for i := 0; i < 4; i++ {
+				c.update(chat("chess", fmt.Sprint(i), "foo", "hi"))
+			}
+
+			c.scrollBy(test.n)
+
+			if c.scroll != test.wantScroll {
+				t.Errorf("expected to be scrolled %d lines, got %d", test.wantScroll, c.scroll)
+			}
+			if len(c.lines) != test.wantLines {
+				t.Errorf("expected %d lines, got %d", test.wantLines, len(c.lines))
+			}
+		})
+	}
+
+	t.Run("short terminals", func(t *testing.T) {
+		for height := 0; height <= 6; height++ {
+			m, _ := newTestModel([]string{"chess"})
+			m.Update(tea.WindowSizeMsg{Width: 80, Height: height})
+			m.Update(chat("chess", "1", "foo", "one"))
+			m.Update(chat("chess", "2", "foo", "two"))
+
+			press(m, "pgup")
+			m.Update(tea.MouseMsg{X: 1, Y: 3, Button: tea.MouseButtonWheelUp})
+			m.View()
+		}
+	})
+}
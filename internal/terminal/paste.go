@@ -0,0 +1,136 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// pasteInterval keeps split pastes under Twitch's limit of 20 messages in 30 seconds
+	pasteInterval = 1500 * time.Millisecond
+)
+
+// pastedMsg sends the next message of a split paste
+type pastedMsg struct {
+	id      int
+	channel *Channel
+	texts   []string
+}
+
+// paste handles a bracketed paste. Text that would be mangled into one message, because it
+// has several lines or is too long, asks whether to send it as separate messages, join it or cancel.
+func (m *Model) paste(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pasted := string(msg.Runes)
+	full := m.textInput.Value() + pasted
+	if !strings.ContainsAny(strings.TrimSpace(pasted), "\r\n") && utf8.RuneCountInString(full) <= maxMessageLength {
+		return m.updateInput(msg)
+	}
+
+	texts := splitMessages(full, maxMessageLength)
+	if len(texts) == 0 {
+		return m, listenForMessages(m)
+	}
+	joined := strings.Join(strings.Fields(full), " ")
+
+	ch := m.channels[m.activeChannel]
+	m.prompt = &prompt{
+		text: fmt.Sprintf("paste %d characters: [s] send as %d messages · [j] join into the input · [c] cancel", utf8.RuneCountInString(joined), len(texts)),
+		actions: map[string]func() tea.Cmd{
+			"s": func() tea.Cmd {
+				if ch == m.mentions {
+					m.status = fmt.Sprintf("%s is read-only", MentionsChannel)
+					return nil
+				}
+				m.textInput.SetValue("")
+				m.updateSuggestions()
+				m.pastes++
+				m.pasting = m.pastes
+				return sendPasted(pastedMsg{id: m.pasting, channel: ch, texts: texts}, 0)
+			},
+			"j": func() tea.Cmd {
+				m.textInput.SetValue(joined)
+				m.textInput.CursorEnd()
+				m.updateSuggestions()
+				return nil
+			},
+			"c": noAction,
+		},
+	}
+	return m, listenForMessages(m)
+}
+
+func sendPasted(p pastedMsg, after time.Duration) tea.Cmd {
+	if after == 0 {
+		return func() tea.Msg { return p }
+	}
+	return tea.Tick(after, func(time.Time) tea.Msg { return p })
+}
+
+// updatePasted sends the next message of a split paste and schedules the rest. Pasted messages are
+// text, never commands, so a pasted line can't whisper, ban or ignore. The paste stops when it's
+// cancelled, the tab changes or a message can't be sent.
+func (m *Model) updatePasted(p pastedMsg) (tea.Model, tea.Cmd) {
+	if p.id != m.pasting {
+		return m, listenForMessages(m)
+	}
+	if p.channel != m.channels[m.activeChannel] {
+		m.stopPaste(fmt.Sprintf("stopped sending pasted messages to %s", p.channel.name))
+		return m, listenForMessages(m)
+	}
+
+	if !m.publish(p.texts[0]) {
+		m.pasting = 0
+		return m, listenForMessages(m)
+	}
+	cmd := m.remember(p.texts[0])
+	p.texts = p.texts[1:]
+	if len(p.texts) == 0 {
+		m.pasting = 0
+		m.status = ""
+		return m, tea.Batch(cmd, listenForMessages(m))
+	}
+	m.status = fmt.Sprintf("sending %d more pasted messages, %s to stop", len(p.texts), m.keys.Cancel.Help().Key)
+	return m, tea.Batch(cmd, sendPasted(p, pasteInterval), listenForMessages(m))
+}
+
+// stopPaste drops the rest of the split paste being sent
+func (m *Model) stopPaste(status string) {
+	m.pasting = 0
+	m.status = status
+}
+
+// splitMessages returns the non-empty lines of text, each split at spaces into parts of at most limit characters
+func splitMessages(text string, limit int) []string {
+	var messages []string
+	for _, l := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		words := strings.Fields(l)
+		var current []rune
+		for _, w := range words {
+			word := []rune(w)
+			for len(word) > limit {
+				if len(current) > 0 {
+					messages = append(messages, string(current))
+					current = nil
+				}
+				messages = append(messages, string(word[:limit]))
+				word = word[limit:]
+			}
+			if len(current) > 0 && len(current)+1+len(word) > limit {
+				messages = append(messages, string(current))
+				current = nil
+			}
+			if len(current) > 0 {
+				current = append(current, ' ')
+			}
+			current = append(current, word...)
+		}
+		if len(current) > 0 {
+			messages = append(messages, string(current))
+		}
+	}
+	return messages
+}
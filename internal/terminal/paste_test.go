@@ -0,0 +1,167 @@
+package terminal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSplitMessages(t *testing.T) {
+	tests := []struct {
+		Name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"lines", "one\ntwo\r\nthree", 10, []string{"one", "two", "three"}},
+		{"empty lines", "one\n\n  \ntwo\n", 10, []string{"one", "two"}},
+		{"spaces collapse", "  one   two  ", 10, []string{"one two"}},
+		{"long line at spaces", "aaa bbb ccc ddd", 7, []string{"aaa bbb", "ccc ddd"}},
+		{"long word", "aaaaaaaaaa bb", 4, []string{"aaaa", "aaaa", "aa", "bb"}},
+		{"long word after words", "a bbbbbbbbbb", 4, []string{"a", "bbbb", "bbbb", "bb"}},
+		{"characters, not bytes", "ééé ééé", 3, []string{"ééé", "ééé"}},
+		{"nothing", " \n ", 10, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := splitMessages(test.text, test.limit); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func pasteKey(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text), Paste: true}
+}
+
+// sendPaste pastes text as separate messages and delivers each of them, calling before ahead of each
+func sendPaste(m *Model, text string, before func(i int)) {
+	m.Update(pasteKey(text))
+	press(m, "s")
+	id, ch := m.pasting, m.channels[m.activeChannel]
+	texts := splitMessages(text, maxMessageLength)
+	for i := range texts {
+		if before != nil {
+			before(i)
+		}
+		m.Update(pastedMsg{id: id, channel: ch, texts: texts[i:]})
+	}
+}
+
+func TestPaste(t *testing.T) {
+	t.Run("one line goes in the input", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"})
+		press(m, "hi ")
+
+		m.Update(pasteKey("there"))
+
+		if m.prompt != nil || m.textInput.Value() != "hi there" {
+			t.Errorf("expected input %q without a prompt, got %q", "hi there", m.textInput.Value())
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+
+		m.Update(pasteKey("one\ntwo"))
+		if m.prompt == nil {
+			t.Fatalf("expected a prompt")
+		}
+		press(m, "j")
+
+		if m.textInput.Value() != "one two" {
+			t.Errorf("expected input %q, got %q", "one two", m.textInput.Value())
+		}
+		if len(ircs["chess"].published) != 0 {
+			t.Errorf("expected nothing published, got %v", ircs["chess"].published)
+		}
+	})
+
+	t.Run("sent as text", func(t *testing.T) {
+		whispers := &mockWhisperIRC{}
+		history := &mockHistory{entries: map[string][]string{}}
+		mod := &mockModIRC{enabled: true, moderator: true}
+		list := &mockIgnoreList{}
+		m, _ := newTestModel(nil, WithHistory(history), WithIgnoreList(list), func(m *Model) {
+			m.channels = append(m.channels, NewChannel(mod, "chess", 0), NewChannel(whispers, "Whispers", 0))
+		})
+
+		sendPaste(m, "hi\n/ban x\n/w foo psst\n/ignore foo\n/split\nbye", nil)
+
+		want := []string{"hi", "/ban x", "/w foo psst", "/ignore foo", "/split", "bye"}
+		if !reflect.DeepEqual(mod.published, want) {
+			t.Errorf("expected %q published, got %q", want, mod.published)
+		}
+		if len(mod.calls) != 0 || len(whispers.whispers) != 0 || len(list.calls) != 0 || m.layout != Single {
+			t.Errorf("expected no commands to run, got %q, %q and %q", mod.calls, whispers.whispers, list.calls)
+		}
+		if !reflect.DeepEqual(history.entries["chess"], want) {
+			t.Errorf("expected history %q, got %q", want, history.entries["chess"])
+		}
+		if m.pasting != 0 {
+			t.Errorf("expected the paste to end")
+		}
+	})
+
+	t.Run("first message replies", func(t *testing.T) {
+		m, ircs := newTestModel([]string{"chess"})
+		m.Update(chat("chess", "1", "foo", "question"))
+		press(m, "ctrl+s", "enter")
+
+		sendPaste(m, "one\ntwo", nil)
+
+		if want := []reply{{parentID: "1", text: "one"}}; !reflect.DeepEqual(ircs["chess"].replies, want) {
+			t.Errorf("expected replies %v, got %v", want, ircs["chess"].replies)
+		}
+		if want := []string{"two"}; !reflect.DeepEqual(ircs["chess"].published, want) {
+			t.Errorf("expected %q published, got %q", want, ircs["chess"].published)
+		}
+	})
+
+	tests := []struct {
+		Name       string
+		keys       map[int][]string // pressed before the message at the index
+		wantSent   []string
+		wantStatus string
+	}{
+		{"cancel", map[int][]string{1: {"esc"}}, []string{"one"}, "stopped sending pasted messages"},
+		{"tab changed", map[int][]string{2: {"tab"}}, []string{"one", "two"}, "stopped sending pasted messages to chess"},
+		{"all sent", nil, []string{"one", "two", "/w foo hi", "four"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			m, ircs := newTestModel([]string{"chess", "other"})
+
+			sendPaste(m, "one\ntwo\n/w foo hi\nfour", func(i int) {
+				press(m, test.keys[i]...)
+			})
+
+			if got := ircs["chess"].published; !reflect.DeepEqual(got, test.wantSent) {
+				t.Errorf("expected %q published, got %q", test.wantSent, got)
+			}
+			if m.status != test.wantStatus {
+				t.Errorf("expected status %q, got %q", test.wantStatus, m.status)
+			}
+			if m.pasting != 0 {
+				t.Errorf("expected the paste to stop")
+			}
+		})
+	}
+
+	t.Run("status counts what's left", func(t *testing.T) {
+		m, _ := newTestModel([]string{"chess"})
+		m.Update(pasteKey("one\ntwo\nthree"))
+		press(m, "s")
+
+		m.Update(pastedMsg{id: m.pasting, channel: m.channels[0], texts: []string{"one", "two", "three"}})
+
+		if !strings.HasPrefix(m.status, "sending 2 more pasted messages") {
+			t.Errorf("expected the remaining messages in the status, got %q", m.status)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package terminal
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// piece is an escape sequence or a grapheme cluster of styled text
+type piece struct {
+	value  string
+	width  int
+	escape bool
+}
+
+// wrap breaks styled text into lines of at most width cells. Lines break at spaces,
+// words longer than a line are broken between graphemes, and styles and hyperlinks
+// open at a break are closed at the end of the line and reopened on the next.
+func wrap(text string, width int) []string {
+	if width <= 0 {
+		return strings.Split(text, "\n")
+	}
+
+	var lines [][]piece
+	var line, word, spaces []piece
+	lineWidth, wordWidth, spacesWidth := 0, 0, 0
+	long := false // the word doesn't fit on a line and is being broken
+
+	newLine := func() {
+		lines = append(lines, line)
+		line, lineWidth = nil, 0
+		spaces, spacesWidth = nil, 0
+	}
+	addWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		if wordWidth > 0 && lineWidth > 0 && lineWidth+spacesWidth+wordWidth > width {
+			newLine()
+		}
+		if lineWidth == 0 && spacesWidth+wordWidth > width {
+			spaces, spacesWidth = nil, 0
+		}
+		if wordWidth == 0 && lineWidth+spacesWidth > width {
+			// escape sequences alone don't break the line, so spaces that don't fit are dropped as at a break
+			spaces, spacesWidth = nil, 0
+		}
+		line = append(append(line, spaces...), word...)
+		lineWidth += spacesWidth + wordWidth
+		word, wordWidth, spaces, spacesWidth = nil, 0, nil, 0
+	}
+
+	for _, p := range pieces(text) {
+		switch {
+		case p.escape:
+			if long {
+				line = append(line, p)
+			} else {
+				word = append(word, p)
+			}
+		case p.value == "\n":
+			addWord()
+			long = false
+			newLine()
+		case p.value == " ":
+			if !long {
+				addWord()
+			}
+			long = false
+			spaces = append(spaces, p)
+			spacesWidth += p.width
+		case long:
+			if lineWidth+p.width > width && lineWidth > 0 {
+				newLine()
+			}
+			line = append(line, p)
+			lineWidth += p.width
+		case wordWidth+p.width > width:
+			// start breaking the word on its own line
+			if lineWidth > 0 {
+				newLine()
+			}
+			line = append(line, word...)
+			lineWidth = wordWidth
+			word, wordWidth = nil, 0
+			long = true
+			if lineWidth+p.width > width && lineWidth > 0 {
+				newLine()
+			}
+			line = append(line, p)
+			lineWidth += p.width
+		default:
+			word = append(word, p)
+			wordWidth += p.width
+		}
+	}
+	addWord()
+	lines = append(lines, line)
+
+	return restyle(lines)
+}
+
+// restyle joins the pieces of each line, carrying open styles and hyperlinks across lines
+func restyle(lines [][]piece) []string {
+	var styles []string // SGR sequences since the last reset
+	link := ""          // the open hyperlink sequence
+
+	wrapped := make([]string, 0, len(lines))
+	for _, l := range lines {
+		var b strings.Builder
+		for _, s := range styles {
+			b.WriteString(s)
+		}
+		b.WriteString(link)
+
+		for _, p := range l {
+			b.WriteString(p.value)
+			if !p.escape {
+				continue
+			}
+			switch {
+			case isSGR(p.value):
+				params := strings.TrimSuffix(strings.TrimPrefix(p.value, "\x1b["), "m")
+				if params == "" || params == "0" {
+					styles = nil
+				} else if strings.HasPrefix(params, "0;") {
+					styles = []string{p.value}
+				} else {
+					styles = append(styles, p.value)
+				}
+			case strings.HasPrefix(p.value, "\x1b]8;"):
+				link = ""
+				if uri := strings.SplitN(strings.TrimRight(p.value, "\a\x1b\\"), ";", 3); len(uri) == 3 && uri[2] != "" {
+					link = p.value
+				}
+			}
+		}
+
+		if link != "" {
+			b.WriteString(ansi.ResetHyperlink())
+		}
+		if len(styles) > 0 {
+			b.WriteString("\x1b[0m")
+		}
+		wrapped = append(wrapped, b.String())
+	}
+	return wrapped
+}
+
+func isSGR(seq string) bool {
+	return strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m")
+}
+
+// pieces splits text into escape sequences and grapheme clusters measured with go-runewidth
+func pieces(text string) []piece {
+	var ps []piece
+	state := -1
+	for len(text) > 0 {
+		if n := escapeLength(text); n > 0 {
+			ps = append(ps, piece{value: text[:n], escape: true})
+			text = text[n:]
+			state = -1
+			continue
+		}
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		ps = append(ps, piece{value: cluster, width: runewidth.StringWidth(cluster)})
+	}
+	return ps
+}
+
+// escapeLength returns the length of the CSI, OSC or two byte escape sequence at the start of s, or 0
+func escapeLength(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	default:
+		return 2
+	}
+}
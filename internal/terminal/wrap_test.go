@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		Name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "hello world", 11, []string{"hello world"}},
+		{"words", "hello world foo", 11, []string{"hello world", "foo"}},
+		{"spaces at break", "hello   world", 5, []string{"hello", "world"}},
+		{"newlines", "a\nb", 10, []string{"a", "b"}},
+		{"no width", "hello world", 0, []string{"hello world"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"long word after text", "hi abcdefgh ok", 4, []string{"hi", "abcd", "efgh", "ok"}},
+		{"url", "see https://example.com/x", 10, []string{"see", "https://ex", "ample.com/", "x"}},
+		{"cjk", "日本語のテキスト", 6, []string{"日本語", "のテキ", "スト"}},
+		{"cjk odd width", "日本語", 5, []string{"日本", "語"}},
+		{"emoji", "👍👍👍", 4, []string{"👍👍", "👍"}},
+		{"zwj sequence", "👨‍👩‍👧 ok", 2, []string{"👨‍👩‍👧", "ok"}},
+		{"zwj sequences", "👨‍👩‍👧👨‍👩‍👧👨‍👩‍👧", 4, []string{"👨‍👩‍👧👨‍👩‍👧", "👨‍👩‍👧"}},
+		{"combining marks", "café café", 4, []string{"café", "café"}},
+		{"style across lines", "\x1b[31mred text here\x1b[0m", 8, []string{"\x1b[31mred text\x1b[0m", "\x1b[31mhere\x1b[0m"}},
+		{"styles reset", "\x1b[1mbold\x1b[0m plain", 5, []string{"\x1b[1mbold\x1b[0m", "plain"}},
+		{"spaces before escapes past the width", "b👍日\x1b[0m   \x1b[0m", 2, []string{"b", "👍", "日\x1b[0m\x1b[0m"}},
+		{"spaces around escapes", "ab \x1b[1m cd", 6, []string{"ab \x1b[1m cd\x1b[0m"}},
+		{"style in long word", "\x1b[32mabcdef\x1b[0m", 3, []string{"\x1b[32mabc\x1b[0m", "\x1b[32mdef\x1b[0m"}},
+		{"hyperlink across lines", "\x1b]8;;https://a.io/xyz\ahttps://a.io/xyz\x1b]8;;\a", 8, []string{
+			"\x1b]8;;https://a.io/xyz\ahttps://\x1b]8;;\a",
+			"\x1b]8;;https://a.io/xyz\aa.io/xyz\x1b]8;;\a",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := wrap(test.text, test.width)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
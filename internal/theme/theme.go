@@ -0,0 +1,180 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Dark    = "dark"
+	Light   = "light"
+	NoColor = "none"
+)
+
+// Theme is the look of ttchat. A style that is set replaces the preset's style entirely.
+type Theme struct {
+	Preset      string `yaml:"preset"` // dark, light or none, the default look when empty
+	File        string `yaml:"file"`   // a yaml theme applied between the preset and this theme's own styles
+	NameColor   string `yaml:"nameColor"`
+	Mention     Style  `yaml:"mention"`
+	Emote       Style  `yaml:"emote"`
+	System      Style  `yaml:"system"`
+	Timestamp   Style  `yaml:"timestamp"`
+	TabActive   Style  `yaml:"tabActive"`
+	TabInactive Style  `yaml:"tabInactive"`
+	Accent      string `yaml:"accent"`     // tab underline, pane separators and the inspector border
+	Background  string `yaml:"background"` // names are made readable against it, the terminal's when empty
+	Prompt      Style  `yaml:"prompt"`
+	Input       Style  `yaml:"input"`
+	Placeholder Style  `yaml:"placeholder"`
+}
+
+// Style is the subset of lipgloss styling that can be set in yaml
+type Style struct {
+	Foreground string `yaml:"foreground"`
+	Background string `yaml:"background"`
+	Bold       bool   `yaml:"bold"`
+	Italic     bool   `yaml:"italic"`
+	Underline  bool   `yaml:"underline"`
+	Faint      bool   `yaml:"faint"`
+	Reverse    bool   `yaml:"reverse"`
+}
+
+var presets = map[string]Theme{
+	"": {
+		NameColor:   "#1E90FF",
+		Mention:     Style{Bold: true, Background: "#6441A5"},
+		Emote:       Style{Italic: true, Foreground: "#FFB31A"},
+		System:      Style{Foreground: "#808080"},
+		Timestamp:   Style{Faint: true},
+		TabActive:   Style{Foreground: "#6441A5"},
+		Placeholder: Style{Foreground: "240"},
+	},
+	Dark: {
+		NameColor:   "#5CACEE",
+		Mention:     Style{Bold: true, Foreground: "#FFFFFF", Background: "#6441A5"},
+		Emote:       Style{Italic: true, Foreground: "#FFB31A"},
+		System:      Style{Foreground: "#8A8A8A"},
+		Timestamp:   Style{Foreground: "#6C6C6C"},
+		TabActive:   Style{Bold: true, Foreground: "#A970FF"},
+		TabInactive: Style{Foreground: "#BCBCBC"},
+		Accent:      "#A970FF",
+		Prompt:      Style{Foreground: "#A970FF"},
+		Input:       Style{Foreground: "#EEEEEE"},
+		Placeholder: Style{Foreground: "#6C6C6C"},
+	},
+	Light: {
+		NameColor:   "#0057B8",
+		Mention:     Style{Bold: true, Foreground: "#FFFFFF", Background: "#6441A5"},
+		Emote:       Style{Italic: true, Foreground: "#B05E00"},
+		System:      Style{Foreground: "#6C6C6C"},
+		Timestamp:   Style{Foreground: "#8A8A8A"},
+		TabActive:   Style{Bold: true, Foreground: "#6441A5"},
+		TabInactive: Style{Foreground: "#3A3A3A"},
+		Accent:      "#6441A5",
+		Prompt:      Style{Foreground: "#6441A5"},
+		Input:       Style{Foreground: "#1C1C1C"},
+		Placeholder: Style{Foreground: "#8A8A8A"},
+	},
+	NoColor: {
+		Mention:     Style{Bold: true, Reverse: true},
+		Emote:       Style{Italic: true},
+		System:      Style{Faint: true},
+		Timestamp:   Style{Faint: true},
+		TabActive:   Style{Bold: true, Underline: true},
+		Prompt:      Style{Bold: true},
+		Placeholder: Style{Faint: true},
+	},
+}
+
+// Load resolves t into a complete theme from its preset, its file and its own styles, in that order.
+// noColor, set from NO_COLOR, forces the none preset.
+func Load(t Theme, noColor bool) (Theme, error) {
+	preset := t.Preset
+	var file Theme
+	if t.File != "" {
+		f, err := os.ReadFile(t.File)
+		if err != nil {
+			return Theme{}, err
+		}
+		err = yaml.Unmarshal(f, &file)
+		if err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %v", t.File, err)
+		}
+		if preset == "" {
+			preset = file.Preset
+		}
+	}
+	if noColor {
+		preset = NoColor
+	}
+
+	base, ok := presets[preset]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme preset %q, expected %q, %q or %q", preset, Dark, Light, NoColor)
+	}
+	base.Preset = preset
+	if noColor {
+		return base, nil
+	}
+	return base.merge(file).merge(t), nil
+}
+
+// merge returns t with every style that is set in o
+func (t Theme) merge(o Theme) Theme {
+	if o.NameColor != "" {
+		t.NameColor = o.NameColor
+	}
+	if o.Accent != "" {
+		t.Accent = o.Accent
+	}
+	if o.Background != "" {
+		t.Background = o.Background
+	}
+	for _, s := range []struct{ dst, src *Style }{
+		{&t.Mention, &o.Mention},
+		{&t.Emote, &o.Emote},
+		{&t.System, &o.System},
+		{&t.Timestamp, &o.Timestamp},
+		{&t.TabActive, &o.TabActive},
+		{&t.TabInactive, &o.TabInactive},
+		{&t.Prompt, &o.Prompt},
+		{&t.Input, &o.Input},
+		{&t.Placeholder, &o.Placeholder},
+	} {
+		if *s.src != (Style{}) {
+			*s.dst = *s.src
+		}
+	}
+	return t
+}
+
+// Lipgloss returns s as a lipgloss style
+func (s Style) Lipgloss() lipgloss.Style {
+	l := lipgloss.NewStyle()
+	if s.Bold {
+		l = l.Bold(true)
+	}
+	if s.Italic {
+		l = l.Italic(true)
+	}
+	if s.Underline {
+		l = l.Underline(true)
+	}
+	if s.Faint {
+		l = l.Faint(true)
+	}
+	if s.Reverse {
+		l = l.Reverse(true)
+	}
+	if s.Foreground != "" {
+		l = l.Foreground(lipgloss.Color(s.Foreground))
+	}
+	if s.Background != "" {
+		l = l.Background(lipgloss.Color(s.Background))
+	}
+	return l
+}
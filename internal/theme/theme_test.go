@@ -0,0 +1,90 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "theme.yaml")
+	err := os.WriteFile(file, []byte("preset: light\nnameColor: \"#111111\"\nmention:\n  bold: true\n  foreground: \"#222222\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name        string
+		theme       Theme
+		noColor     bool
+		wantPreset  string
+		wantName    string
+		wantMention Style
+		wantSystem  Style
+	}{
+		{
+			"default",
+			Theme{},
+			false,
+			"",
+			"#1E90FF",
+			Style{Bold: true, Background: "#6441A5"},
+			Style{Foreground: "#808080"},
+		},
+		{
+			"preset with override",
+			Theme{Preset: Dark, System: Style{Italic: true}},
+			false,
+			Dark,
+			"#5CACEE",
+			Style{Bold: true, Foreground: "#FFFFFF", Background: "#6441A5"},
+			Style{Italic: true},
+		},
+		{
+			"file then own styles",
+			Theme{File: file, NameColor: "#333333"},
+			false,
+			Light,
+			"#333333",
+			Style{Bold: true, Foreground: "#222222"},
+			Style{Foreground: "#6C6C6C"},
+		},
+		{
+			"no color",
+			Theme{Preset: Dark, NameColor: "#333333"},
+			true,
+			NoColor,
+			"",
+			Style{Bold: true, Reverse: true},
+			Style{Faint: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := Load(test.theme, test.noColor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Preset != test.wantPreset {
+				t.Errorf("expected preset %q, got %q", test.wantPreset, got.Preset)
+			}
+			if got.NameColor != test.wantName {
+				t.Errorf("expected name color %q, got %q", test.wantName, got.NameColor)
+			}
+			if got.Mention != test.wantMention {
+				t.Errorf("expected mention %+v, got %+v", test.wantMention, got.Mention)
+			}
+			if got.System != test.wantSystem {
+				t.Errorf("expected system %+v, got %+v", test.wantSystem, got.System)
+			}
+		})
+	}
+
+	t.Run("unknown preset", func(t *testing.T) {
+		_, err := Load(Theme{Preset: "solarized"}, false)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
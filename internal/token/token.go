@@ -0,0 +1,72 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// Store keeps the access token of each account in its own file of dir
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Load returns the saved token of account, or an empty string when there is none
+func (s *Store) Load(account string) (string, error) {
+	path, err := s.path(account)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Save replaces the token of account, readable only by the user
+func (s *Store) Save(account string, token string) error {
+	path, err := s.path(account)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(s.dir, 0o700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(token), 0o600)
+}
+
+// Delete removes the token of account, such as when Twitch no longer accepts it
+func (s *Store) Delete(account string) error {
+	path, err := s.path(account)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) path(account string) (string, error) {
+	if !validName.MatchString(account) || account == "." || account == ".." {
+		return "", fmt.Errorf("invalid account name %q", account)
+	}
+	return filepath.Join(s.dir, account), nil
+}
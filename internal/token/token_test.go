@@ -0,0 +1,66 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tokens")
+	s := NewStore(dir)
+
+	got, err := s.Load("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("expected no token, got %q", got)
+	}
+
+	if err := s.Save("main", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("bot", "xyz"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		account string
+		want    string
+	}{
+		{"main", "abc"},
+		{"bot", "xyz"},
+		{"other", ""},
+	}
+	for _, test := range tests {
+		got, err := s.Load(test.account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("expected token %q for %s, got %q", test.want, test.account, got)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode %v, got %v", os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	if err := s.Delete("main"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Load("main"); got != "" {
+		t.Errorf("expected deleted token, got %q", got)
+	}
+
+	for _, name := range []string{"", "..", "a/b"} {
+		if err := s.Save(name, "abc"); err == nil {
+			t.Errorf("expected an error saving account %q", name)
+		}
+	}
+}
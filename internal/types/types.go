@@ -1,23 +1,56 @@
 package types
 
+import "time"
+
 type Message interface {
+	GetID() string
 	GetChannel() string
+	GetLogin() string
 	GetName() string
 	GetColor() string
 	GetText() string
+	GetReply() *Reply
+	GetBadges() map[string]int
+	GetTime() time.Time
+	GetSource() string
+	IsHighlighted() bool
 }
 
 type PrivateMessage struct {
-	Channel string
-	Name    string
-	Color   string
-	Text    string
+	ID          string
+	Channel     string
+	UserID      string
+	Login       string
+	Name        string
+	Color       string
+	Text        string
+	Reply       *Reply
+	Badges      map[string]int
+	Time        time.Time
+	EmoteOnly   bool
+	Source      string // the channel a message was sent in when it's shown in another tab
+	Highlighted bool   // mentions the user or matches a highlight rule
+}
+
+// Reply is the message a PrivateMessage is replying to
+type Reply struct {
+	ParentID   string
+	ParentName string
+	ParentText string
+}
+
+func (m PrivateMessage) GetID() string {
+	return m.ID
 }
 
 func (m PrivateMessage) GetChannel() string {
 	return m.Channel
 }
 
+func (m PrivateMessage) GetLogin() string {
+	return m.Login
+}
+
 func (m PrivateMessage) GetName() string {
 	return m.Name
 }
@@ -29,3 +62,44 @@ func (m PrivateMessage) GetText() string {
 func (m PrivateMessage) GetColor() string {
 	return m.Color
 }
+
+func (m PrivateMessage) GetReply() *Reply {
+	return m.Reply
+}
+
+func (m PrivateMessage) GetBadges() map[string]int {
+	return m.Badges
+}
+
+func (m PrivateMessage) GetTime() time.Time {
+	return m.Time
+}
+
+func (m PrivateMessage) GetSource() string {
+	return m.Source
+}
+
+func (m PrivateMessage) IsHighlighted() bool {
+	return m.Highlighted
+}
+
+// WhisperMessage is a private message between the user and Conversation
+type WhisperMessage struct {
+	PrivateMessage
+	Conversation string
+}
+
+// ClearChat is a timeout or ban of Target, or a clear of the whole chat when Target is empty
+type ClearChat struct {
+	PrivateMessage
+	Target   string
+	Duration time.Duration // zero for a ban
+}
+
+// UserInfo is what Twitch knows about a user beyond their chat messages
+type UserInfo struct {
+	Login       string
+	CreatedAt   time.Time
+	FollowedAt  time.Time // zero when not following
+	FollowKnown bool      // false when the follow status couldn't be read
+}